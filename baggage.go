@@ -0,0 +1,269 @@
+package ctxf
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// BaggageHeader is the name of the W3C Baggage HTTP header.
+// It is used as the carrier key by BaggagePropagator.
+const BaggageHeader = "baggage"
+
+// Limits defined by the W3C Baggage specification.
+const (
+	MaxBaggageMembers = 180
+	MaxBaggageBytes   = 8192
+)
+
+// BaggageTypeProperty is the name of the baggage member property
+// holding the kind of the field value.
+const BaggageTypeProperty = "ctxf-type"
+
+// BaggagePropagator is a Propagator using the W3C Baggage format.
+//
+// Only scalar fields with keys listed in AllowedKeys leave the process,
+// and only members with such keys are accepted from the remote party.
+// Members with keys reserved by ReserveKeys are never accepted.
+// Sensitive fields are never propagated, the RedactionPolicy set by
+// SetRedactionPolicy is applied to the other fields.
+// Groups are flattened, so members of a group are allowed by their dotted keys, e.g. "http.method".
+// Each field is encoded as a baggage member with a property holding the kind of
+// its value, so that a typed field can be reconstructed on the other side.
+type BaggagePropagator struct {
+	AllowedKeys []string
+}
+
+// NewBaggagePropagator returns a new BaggagePropagator which allows fields
+// with the given keys to leave and enter the process.
+func NewBaggagePropagator(allowedKeys ...string) BaggagePropagator {
+	return BaggagePropagator{allowedKeys}
+}
+
+// Inject adds fields associated with the ctx to the baggage entry of the carrier.
+// Members already present in the carrier are kept. Members that do not fit
+// into the limits defined by the specification are dropped.
+func (p BaggagePropagator) Inject(ctx context.Context, carrier Carrier) {
+	value := carrier.Get(BaggageHeader)
+	members := 0
+	if value != "" {
+		members = len(splitBaggage(value))
+	}
+
+	fields := Flatten(exportLabels(Unique(Fields(ctx))))
+	for i := range fields {
+		if members >= MaxBaggageMembers {
+			break
+		}
+		if !contains(p.AllowedKeys, fields[i].Key) {
+			continue
+		}
+		member, ok := formatBaggageMember(fields[i])
+		if !ok {
+			continue
+		}
+		if len(value) != 0 {
+			if len(value)+1+len(member) > MaxBaggageBytes {
+				continue
+			}
+			value += ","
+		} else if len(member) > MaxBaggageBytes {
+			continue
+		}
+		value += member
+		members++
+	}
+
+	if value != "" {
+		carrier.Set(BaggageHeader, value)
+	}
+}
+
+// Extract returns a Context with fields associated with the ctx
+// extended with fields from the baggage entry of the carrier.
+// Members with keys not listed in AllowedKeys or reserved by ReserveKeys are ignored,
+// as well as members exceeding the limits defined by the specification.
+// Extracted fields are not validated against the Schema set by SetSchema.
+func (p BaggagePropagator) Extract(ctx context.Context, carrier Carrier) Context {
+	c := DecodeOptional(ctx)
+
+	value := carrier.Get(BaggageHeader)
+	if value == "" {
+		return c
+	}
+
+	var fields []Field
+	size := 0
+	for i, member := range splitBaggage(value) {
+		if i == MaxBaggageMembers {
+			break
+		}
+		size += len(member)
+		if size > MaxBaggageBytes {
+			break
+		}
+		size++
+
+		field, ok := parseBaggageMember(member)
+		if ok && contains(p.AllowedKeys, field.Key) && !IsReservedKey(field.Key) {
+			fields = append(fields, field)
+		}
+	}
+
+	return c.append(fields)
+}
+
+// InjectHeader adds fields associated with the ctx to the baggage header of the h, see Inject for details.
+func (p BaggagePropagator) InjectHeader(ctx context.Context, h http.Header) {
+	p.Inject(ctx, HeaderCarrier(h))
+}
+
+// ExtractHeader returns a Context with fields associated with the ctx
+// extended with fields from the baggage header of the h, see Extract for details.
+func (p BaggagePropagator) ExtractHeader(ctx context.Context, h http.Header) Context {
+	return p.Extract(ctx, HeaderCarrier(h))
+}
+
+var _ Propagator = BaggagePropagator{}
+
+func formatBaggageMember(field Field) (string, bool) {
+	if !isToken(field.Key) {
+		return "", false
+	}
+
+	text, kind, ok := formatText(field.Value)
+	if !ok {
+		return "", false
+	}
+
+	var b strings.Builder
+	b.WriteString(field.Key)
+	b.WriteByte('=')
+	percentEncode(&b, text)
+	if kind != kindString {
+		b.WriteByte(';')
+		b.WriteString(BaggageTypeProperty)
+		b.WriteByte('=')
+		b.WriteString(kind)
+	}
+
+	return b.String(), true
+}
+
+func parseBaggageMember(member string) (Field, bool) {
+	parts := strings.Split(member, ";")
+
+	key, text, ok := strings.Cut(parts[0], "=")
+	key = strings.TrimSpace(key)
+	if !ok || !isToken(key) {
+		return Field{}, false
+	}
+	text, ok = percentDecode(strings.TrimSpace(text))
+	if !ok {
+		return Field{}, false
+	}
+
+	kind := kindString
+	for _, property := range parts[1:] {
+		name, value, _ := strings.Cut(property, "=")
+		if strings.TrimSpace(name) == BaggageTypeProperty {
+			kind = strings.TrimSpace(value)
+		}
+	}
+
+	value, ok := parseText(kind, text)
+	if !ok {
+		return String(key, text), true
+	}
+
+	return Field{key, value}, true
+}
+
+func splitBaggage(value string) []string {
+	members := strings.Split(value, ",")
+	n := 0
+	for _, member := range members {
+		member = strings.TrimSpace(member)
+		if member != "" {
+			members[n] = member
+			n++
+		}
+	}
+
+	return members[:n]
+}
+
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0 {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func isBaggageOctet(c byte) bool {
+	return c == 0x21 || c >= 0x23 && c <= 0x2b || c >= 0x2d && c <= 0x3a || c >= 0x3c && c <= 0x5b || c >= 0x5d && c <= 0x7e
+}
+
+func percentEncode(b *strings.Builder, s string) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isBaggageOctet(c) && c != '%' {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(upperHex[c>>4])
+			b.WriteByte(upperHex[c&0xf])
+		}
+	}
+}
+
+func percentDecode(s string) (string, bool) {
+	if strings.IndexByte(s, '%') < 0 {
+		return s, true
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", false
+		}
+		hi, ok1 := unhex(s[i+1])
+		lo, ok2 := unhex(s[i+2])
+		if !ok1 || !ok2 {
+			return "", false
+		}
+		b.WriteByte(hi<<4 | lo)
+		i += 2
+	}
+
+	return b.String(), true
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	default:
+		return 0, false
+	}
+}
+
+const upperHex = "0123456789ABCDEF"
@@ -0,0 +1,177 @@
+package ctxf
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaggageInject(t *testing.T) {
+	p := NewBaggagePropagator("tenant", "user_id", "ratio", "note", "elapsed")
+	ctx := New(context.Background(),
+		String("tenant", "acme"),
+		Int64("user_id", 42),
+		String("secret", "s3cr3t"),
+		Float64("ratio", 0.5),
+		String("note", "a b,c;d%"),
+		Duration("elapsed", time.Second),
+	)
+
+	header := http.Header{}
+	p.Inject(ctx, HeaderCarrier(header))
+	assert.Equal(t,
+		"tenant=acme,user_id=42;ctxf-type=int64,ratio=0.5;ctxf-type=float64,note=a%20b%2Cc%3Bd%25,elapsed=1s;ctxf-type=duration",
+		header.Get(BaggageHeader),
+	)
+}
+
+func TestBaggageInjectKeepsExistingMembers(t *testing.T) {
+	p := NewBaggagePropagator("tenant")
+	ctx := New(context.Background(), String("tenant", "acme"))
+
+	header := http.Header{}
+	header.Add(BaggageHeader, "other=1")
+	header.Add(BaggageHeader, "more=2")
+	p.Inject(ctx, HeaderCarrier(header))
+	assert.Equal(t, []string{"other=1,more=2,tenant=acme"}, header.Values(BaggageHeader))
+}
+
+func TestBaggageInjectNothingAllowed(t *testing.T) {
+	var p BaggagePropagator
+	ctx := New(context.Background(), String("tenant", "acme"))
+
+	header := http.Header{}
+	p.Inject(ctx, HeaderCarrier(header))
+	assert.Empty(t, header.Values(BaggageHeader))
+}
+
+func TestBaggageInjectLimits(t *testing.T) {
+	var keys []string
+	var fields []Field
+	for i := 0; i != 2*MaxBaggageMembers; i++ {
+		key := "k" + strconv.Itoa(i)
+		keys = append(keys, key)
+		fields = append(fields, Int(key, i))
+	}
+	fields = append(fields, String("big", strings.Repeat("x", MaxBaggageBytes)))
+	keys = append(keys, "big")
+
+	header := http.Header{}
+	NewBaggagePropagator(keys...).Inject(New(context.Background(), fields...), HeaderCarrier(header))
+	value := header.Get(BaggageHeader)
+	assert.Equal(t, MaxBaggageMembers, len(strings.Split(value, ",")))
+	assert.LessOrEqual(t, len(value), MaxBaggageBytes)
+
+	header = http.Header{}
+	NewBaggagePropagator("big", "small").Inject(New(context.Background(), String("big", strings.Repeat("x", MaxBaggageBytes)), Int("small", 1)), HeaderCarrier(header))
+	assert.Equal(t, "small=1;ctxf-type=int", header.Get(BaggageHeader))
+}
+
+func TestBaggageInjectOversizedHeader(t *testing.T) {
+	incoming := make([]string, MaxBaggageMembers+1)
+	for i := range incoming {
+		incoming[i] = "m" + strconv.Itoa(i) + "=1"
+	}
+
+	carrier := MapCarrier{}
+	carrier.Set(BaggageHeader, strings.Join(incoming, ","))
+	NewBaggagePropagator("user").Inject(New(context.Background(), String("user", "joe")), carrier)
+	assert.Equal(t, strings.Join(incoming, ","), carrier.Get(BaggageHeader))
+}
+
+func TestBaggageExtract(t *testing.T) {
+	header := http.Header{}
+	header.Add(BaggageHeader, "tenant=acme, user_id = 42 ; ctxf-type=int64 ;other")
+	header.Add(BaggageHeader, "note=a%20b%2Cc,bad=%zz,ratio=x;ctxf-type=float64,flag;p,=1")
+	header.Add(BaggageHeader, "unknown=1,task=forged")
+
+	base := New(context.Background(), String("request_id", "r1"))
+	ctx := NewBaggagePropagator("tenant", "user_id", "note", "bad", "ratio", "flag", "task").Extract(base, HeaderCarrier(header))
+	assert.Equal(t, []Field{
+		String("request_id", "r1"),
+		String("tenant", "acme"),
+		Int64("user_id", 42),
+		String("note", "a b,c"),
+		String("ratio", "x"),
+	}, ctx.Fields())
+}
+
+func TestBaggageHeader(t *testing.T) {
+	p := NewBaggagePropagator("tenant")
+	header := http.Header{}
+	p.InjectHeader(New(context.Background(), String("tenant", "acme")), header)
+	assert.Equal(t, "tenant=acme", header.Get(BaggageHeader))
+	assert.Equal(t, []Field{String("tenant", "acme")}, p.ExtractHeader(context.Background(), header).Fields())
+}
+
+func TestBaggageExtractWithoutHeader(t *testing.T) {
+	ctx := NewBaggagePropagator().Extract(context.Background(), HeaderCarrier{})
+	assert.Nil(t, ctx.Fields())
+}
+
+func TestBaggageExtractLimits(t *testing.T) {
+	var members []string
+	for i := 0; i != 2*MaxBaggageMembers; i++ {
+		members = append(members, "k"+strconv.Itoa(i)+"=1")
+	}
+	header := http.Header{}
+	header.Set(BaggageHeader, strings.Join(members, ","))
+
+	var keys []string
+	for i := 0; i != 2*MaxBaggageMembers; i++ {
+		keys = append(keys, "k"+strconv.Itoa(i))
+	}
+	ctx := NewBaggagePropagator(keys...).Extract(context.Background(), HeaderCarrier(header))
+	assert.Len(t, ctx.Fields(), MaxBaggageMembers)
+
+	ctx = NewBaggagePropagator(keys[MaxBaggageMembers-1:]...).Extract(context.Background(), HeaderCarrier(header))
+	assert.Len(t, ctx.Fields(), 1)
+
+	header.Set(BaggageHeader, "a=1,big="+strings.Repeat("x", MaxBaggageBytes)+",b=2")
+	ctx = NewBaggagePropagator("a", "big", "b").Extract(context.Background(), HeaderCarrier(header))
+	assert.Equal(t, []Field{String("a", "1")}, ctx.Fields())
+}
+
+func TestBaggageRoundTrip(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	fields := []Field{
+		Bool("bool", true),
+		Int("int", -1),
+		Int8("int8", -8),
+		Int16("int16", -16),
+		Int32("int32", -32),
+		Int64("int64", -64),
+		Uint("uint", 1),
+		Uint8("uint8", 8),
+		Uint16("uint16", 16),
+		Uint32("uint32", 32),
+		Uint64("uint64", 64),
+		Float32("float32", 0.25),
+		Float64("float64", 0.125),
+		Duration("duration", time.Minute),
+		Time("time", ts),
+		String("string", "ünïcode & spaces"),
+		Bytes("bytes", []byte{0, 1, 2}),
+	}
+	keys := make([]string, len(fields))
+	for i := range fields {
+		keys[i] = fields[i].Key
+	}
+	p := NewBaggagePropagator(keys...)
+
+	header := http.Header{}
+	p.Inject(New(context.Background(), fields...), HeaderCarrier(header))
+	ctx := p.Extract(context.Background(), HeaderCarrier(header))
+	require.Len(t, ctx.Fields(), len(fields))
+	for i, field := range ctx.Fields() {
+		assert.Equal(t, fields[i].Key, field.Key)
+		assert.Equal(t, fields[i].Value.Type(), field.Value.Type(), field.Key)
+	}
+	assert.Equal(t, fields[:len(fields)-1], ctx.Fields()[:len(fields)-1])
+}
@@ -0,0 +1,86 @@
+package ctxf
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Carrier is a storage of string key-value pairs used by a Propagator
+// to transfer fields across process boundaries, e.g. HTTP headers,
+// gRPC metadata or message queue headers.
+type Carrier interface {
+	// Get returns the value associated with the key or an empty string if there is none.
+	Get(key string) string
+	// Set replaces the value associated with the key.
+	Set(key, value string)
+	// Keys lists the keys stored in the carrier.
+	Keys() []string
+}
+
+// MapCarrier is a Carrier backed by map[string]string.
+type MapCarrier map[string]string
+
+// Get implements Carrier.
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set implements Carrier.
+func (c MapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys implements Carrier.
+func (c MapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// HeaderCarrier is a Carrier backed by http.Header.
+// Keys are canonicalized by http.Header.
+// Get combines multiple values of the same key with commas.
+type HeaderCarrier http.Header
+
+// Get implements Carrier.
+func (c HeaderCarrier) Get(key string) string {
+	return strings.Join(http.Header(c).Values(key), ",")
+}
+
+// Set implements Carrier.
+func (c HeaderCarrier) Set(key, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// Keys implements Carrier.
+func (c HeaderCarrier) Keys() []string {
+	return MultiMapCarrier(c).Keys()
+}
+
+// MultiMapCarrier is a Carrier backed by map[string][]string,
+// e.g. gRPC metadata.MD. Keys are used as is.
+// Get combines multiple values of the same key with commas.
+type MultiMapCarrier map[string][]string
+
+// Get implements Carrier.
+func (c MultiMapCarrier) Get(key string) string {
+	return strings.Join(c[key], ",")
+}
+
+// Set implements Carrier.
+func (c MultiMapCarrier) Set(key, value string) {
+	c[key] = []string{value}
+}
+
+// Keys implements Carrier.
+func (c MultiMapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,37 @@
+package ctxf
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapCarrier(t *testing.T) {
+	c := MapCarrier{"a": "1"}
+	c.Set("b", "2")
+	c.Set("a", "3")
+	assert.Equal(t, "3", c.Get("a"))
+	assert.Equal(t, "2", c.Get("b"))
+	assert.Equal(t, "", c.Get("c"))
+	assert.ElementsMatch(t, []string{"a", "b"}, c.Keys())
+}
+
+func TestHeaderCarrier(t *testing.T) {
+	header := http.Header{}
+	header.Add("Baggage", "a=1")
+	header.Add("Baggage", "b=2")
+	c := HeaderCarrier(header)
+	assert.Equal(t, "a=1,b=2", c.Get("baggage"))
+	c.Set("x-tenant", "acme")
+	assert.Equal(t, "acme", header.Get("X-Tenant"))
+	assert.ElementsMatch(t, []string{"Baggage", "X-Tenant"}, c.Keys())
+}
+
+func TestMultiMapCarrier(t *testing.T) {
+	c := MultiMapCarrier{"baggage": {"a=1", "b=2"}}
+	assert.Equal(t, "a=1,b=2", c.Get("baggage"))
+	c.Set("baggage", "c=3")
+	assert.Equal(t, []string{"c=3"}, c["baggage"])
+	assert.Equal(t, []string{"baggage"}, c.Keys())
+}
@@ -0,0 +1,15 @@
+// Command ctxflint reports misuse of the ctxf package.
+//
+// Usage:
+//
+//	ctxflint [-key-pattern regexp] [-fix] packages...
+package main
+
+import (
+	"github.com/pamburus/ctxf/ctxflint"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(ctxflint.Analyzer)
+}
@@ -2,6 +2,7 @@ package ctxf
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/pamburus/valf"
@@ -11,7 +12,7 @@ import (
 // and getting all previously added fields.
 type Context struct {
 	parent context.Context
-	fields []Field
+	fields *chunk
 }
 
 // Deadline delegates the call to the context.Context.
@@ -42,24 +43,86 @@ func (c Context) Value(k interface{}) interface{} {
 }
 
 // With returns a new Context with provided fields appended to its fields.
+// It takes time proportional to the number of provided fields regardless
+// of the number of fields already associated with the context.
+// Fields are validated against the Schema set by SetSchema, if any.
 func (c Context) With(fields ...Field) Context {
 	snapshot(fields)
+	validate(fields)
 
-	f := c.fields
-	if len(f) == 0 {
-		f = fields
-	} else {
-		f = append(f, fields...)
+	return c.append(fields)
+}
+
+// append returns a new Context with the fields appended without validation.
+func (c Context) append(fields []Field) Context {
+	if len(fields) == 0 {
+		return c
 	}
 
-	c.fields = f[0:len(f):len(f)]
+	c.fields = newChunk(c.fields, fields)
 
 	return c
 }
 
 // Fields returns fields associated with the context.
 func (c Context) Fields() []Field {
-	return c.fields
+	return c.fields.Fields()
+}
+
+// UniqueFields returns fields associated with the context with duplicate keys removed.
+// Each key appears at the position of its first occurrence and holds the value of its last occurrence.
+func (c Context) UniqueFields() []Field {
+	return Unique(c.Fields())
+}
+
+// Lookup returns the last field with the given key associated with the context.
+func (c Context) Lookup(key string) (Field, bool) {
+	for ch := c.fields; ch != nil && ch.namespace == ""; ch = ch.prev {
+		for i := len(ch.fields) - 1; i >= 0; i-- {
+			if ch.fields[i].Key == key {
+				return ch.fields[i], true
+			}
+		}
+	}
+
+	return Field{}, false
+}
+
+// Without returns a new Context with all fields having any of the given keys removed.
+func (c Context) Without(keys ...string) Context {
+	base, fields := c.fields.level()
+
+	n := 0
+	for i := range fields {
+		if contains(keys, fields[i].Key) {
+			n++
+		}
+	}
+	if n == 0 {
+		return c
+	}
+
+	f := make([]Field, 0, len(fields)-n)
+	for i := range fields {
+		if !contains(keys, fields[i].Key) {
+			f = append(f, fields[i])
+		}
+	}
+
+	c.fields = newChunk(base, f)
+
+	return c
+}
+
+// Replace returns a new Context with all fields having the same keys as
+// provided fields removed and provided fields appended to its fields.
+func (c Context) Replace(fields ...Field) Context {
+	keys := make([]string, len(fields))
+	for i := range fields {
+		keys[i] = fields[i].Key
+	}
+
+	return c.Without(keys...).With(fields...)
 }
 
 // WithDeadline returns a copy of the c with the deadline adjusted
@@ -102,6 +165,56 @@ func (c Context) WithCancel() (Context, context.CancelFunc) {
 	return c, cancel
 }
 
+// WithCancelCause behaves like WithCancel but returns a CancelCauseFunc instead of a CancelFunc.
+// Calling cancel with a non-nil error records that error as the cause of cancellation,
+// it can be retrieved with Cause.
+func (c Context) WithCancelCause() (Context, context.CancelCauseFunc) {
+	var cancel context.CancelCauseFunc
+	c.parent, cancel = context.WithCancelCause(c.parent)
+
+	return c, cancel
+}
+
+// WithDeadlineCause behaves like WithDeadline but also sets the cause of
+// the returned Context when the deadline is exceeded.
+func (c Context) WithDeadlineCause(deadline time.Time, cause error) (Context, context.CancelFunc) {
+	var cancel context.CancelFunc
+	c.parent, cancel = context.WithDeadlineCause(c.parent, deadline, cause)
+
+	return c, cancel
+}
+
+// WithTimeoutCause behaves like WithTimeout but also sets the cause of
+// the returned Context when the timeout expires.
+func (c Context) WithTimeoutCause(timeout time.Duration, cause error) (Context, context.CancelFunc) {
+	var cancel context.CancelFunc
+	c.parent, cancel = context.WithTimeoutCause(c.parent, timeout, cause)
+
+	return c, cancel
+}
+
+// Cause returns a non-nil error explaining why c was canceled.
+// See context.Cause for details.
+func (c Context) Cause() error {
+	return context.Cause(c.parent)
+}
+
+// AfterFunc arranges to call f in its own goroutine after c is done.
+// See context.AfterFunc for details.
+func (c Context) AfterFunc(f func()) (stop func() bool) {
+	return context.AfterFunc(c.parent, f)
+}
+
+// Detach returns a copy of c which keeps its fields and values
+// but is not canceled when c is canceled.
+// The returned Context has no deadline and its Done channel is nil.
+// It is useful for background work which should outlive the current operation.
+func (c Context) Detach() Context {
+	c.parent = context.WithoutCancel(c.parent)
+
+	return c
+}
+
 // WithValue returns a copy of Context in which the value associated with key is
 // value.
 func (c Context) WithValue(key, value interface{}) Context {
@@ -116,10 +229,42 @@ func (c Context) Encode() context.Context {
 }
 
 // New returns a new Context with provided fields appended to it.
+// Fields associated with the parent are not inherited, use NewInherited to extend them.
+// Fields are validated against the Schema set by SetSchema, if any.
 func New(parent context.Context, fields ...Field) Context {
 	snapshot(fields)
+	validate(fields)
+
+	if len(fields) == 0 {
+		return Context{parent, nil}
+	}
+
+	return Context{parent, newChunk(nil, fields)}
+}
 
-	return Context{parent, fields}
+// NewInherited returns a new Context with fields associated with the parent, if any,
+// and provided fields appended to them. The parent may be a standard context
+// derived from a Context, its fields are found with Decode.
+// Fields are validated against the Schema set by SetSchema, if any.
+func NewInherited(parent context.Context, fields ...Field) Context {
+	return DecodeOptional(parent).With(fields...)
+}
+
+// NewIsolated returns a new Context with provided fields only.
+// Fields associated with the parent are not inherited and are hidden from
+// Fields and Decode called on the returned Context and contexts derived from it.
+// Fields are validated against the Schema set by SetSchema, if any.
+func NewIsolated(parent context.Context, fields ...Field) Context {
+	snapshot(fields)
+	validate(fields)
+
+	return Context{parent, newChunk(nil, fields)}
+}
+
+// WithoutCancel returns a Context with fields and values associated with the ctx
+// which is not canceled when the ctx is canceled. See Context.Detach for details.
+func WithoutCancel(ctx context.Context) Context {
+	return DecodeOptional(ctx).Detach()
 }
 
 // Fields returns all fields from context previously added to it with New.
@@ -129,7 +274,7 @@ func Fields(ctx context.Context) []Field {
 		return nil
 	}
 
-	return c.fields
+	return c.Fields()
 }
 
 // Decode retreives fields associated with the ctx and returns Context
@@ -146,7 +291,7 @@ func Decode(ctx context.Context) (Context, bool) {
 			return Context{ctx, nil}, false
 		}
 
-		return Context{ctx, value.([]Field)}, true
+		return Context{ctx, value.(*chunk)}, true
 	}
 }
 
@@ -158,10 +303,194 @@ func DecodeOptional(ctx context.Context) Context {
 	return c
 }
 
+// Unique returns fields with duplicate keys removed.
+// Each key appears at the position of its first occurrence and holds the value of its last occurrence.
+// If there are no duplicate keys, fields are returned as is.
+func Unique(fields []Field) []Field {
+	if !hasDuplicates(fields) {
+		return fields
+	}
+
+	result := make([]Field, 0, len(fields))
+	index := make(map[string]int, len(fields))
+	for i := range fields {
+		if j, ok := index[fields[i].Key]; ok {
+			result[j] = fields[i]
+		} else {
+			index[fields[i].Key] = len(result)
+			result = append(result, fields[i])
+		}
+	}
+
+	return result[0:len(result):len(result)]
+}
+
 type key struct{}
 
+// chunk is an immutable node of a persistent list of fields.
+// Each chunk holds fields added by a single call to New or With
+// and refers to the chunk holding previously added fields.
+// A chunk with non-empty namespace holds no fields and marks the start of
+// a namespace which nests fields of all subsequent chunks.
+type chunk struct {
+	prev       *chunk
+	fields     []Field
+	size       int
+	namespace  string
+	namespaced bool
+	once       sync.Once
+	flat       []Field
+}
+
+func newChunk(prev *chunk, fields []Field) *chunk {
+	size := len(fields)
+	namespaced := false
+	if prev != nil {
+		size += prev.size
+		namespaced = prev.namespaced
+	}
+
+	own := make([]Field, len(fields))
+	copy(own, fields)
+
+	return &chunk{prev: prev, fields: own, size: size, namespaced: namespaced}
+}
+
+func newNamespace(prev *chunk, name string) *chunk {
+	size := 0
+	if prev != nil {
+		size = prev.size
+	}
+
+	return &chunk{prev: prev, size: size, namespace: name, namespaced: true}
+}
+
+// Fields returns all fields of the chunk and all previous chunks as a flat slice
+// with fields of namespaces folded into groups.
+// The slice is built on first use and reused afterwards.
+func (c *chunk) Fields() []Field {
+	if c == nil {
+		return nil
+	}
+	if c.namespaced {
+		c.once.Do(func() {
+			c.flat = c.fold()
+		})
+
+		return c.flat
+	}
+	if c.prev == nil || c.prev.size == 0 {
+		return c.fields
+	}
+	if len(c.fields) == 0 {
+		return c.prev.Fields()
+	}
+
+	c.once.Do(func() {
+		flat := make([]Field, c.size)
+		n := c.size
+		for ch := c; ch != nil; ch = ch.prev {
+			n -= copy(flat[n-len(ch.fields):n], ch.fields)
+		}
+		c.flat = flat
+	})
+
+	return c.flat
+}
+
+// fold returns all fields of the chunk and all previous chunks
+// with fields of namespaces folded into groups.
+func (c *chunk) fold() []Field {
+	var chain []*chunk
+	for ch := c; ch != nil; ch = ch.prev {
+		chain = append(chain, ch)
+	}
+
+	type level struct {
+		name   string
+		fields []Field
+	}
+	levels := []level{{}}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].namespace != "" {
+			levels = append(levels, level{name: chain[i].namespace})
+		} else {
+			top := &levels[len(levels)-1]
+			top.fields = append(top.fields, chain[i].fields...)
+		}
+	}
+	for i := len(levels) - 1; i != 0; i-- {
+		if len(levels[i].fields) != 0 {
+			levels[i-1].fields = append(levels[i-1].fields, Field{levels[i].name, valf.ConstObject(group(Unique(levels[i].fields)))})
+		}
+	}
+
+	return levels[0].fields
+}
+
+// level returns the chunk starting the innermost namespace and
+// fields added after it. If there are no namespaces, it returns nil and all fields.
+func (c *chunk) level() (*chunk, []Field) {
+	if c == nil || !c.namespaced {
+		return nil, c.Fields()
+	}
+
+	var chain []*chunk
+	ch := c
+	for ; ch.namespace == ""; ch = ch.prev {
+		chain = append(chain, ch)
+	}
+
+	var fields []Field
+	for i := len(chain) - 1; i >= 0; i-- {
+		fields = append(fields, chain[i].fields...)
+	}
+
+	return ch, fields
+}
+
+const maxLinearScanLength = 16
+
+func contains(keys []string, key string) bool {
+	for i := range keys {
+		if keys[i] == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasDuplicates(fields []Field) bool {
+	if len(fields) > maxLinearScanLength {
+		seen := make(map[string]struct{}, len(fields))
+		for i := range fields {
+			if _, ok := seen[fields[i].Key]; ok {
+				return true
+			}
+			seen[fields[i].Key] = struct{}{}
+		}
+
+		return false
+	}
+
+	for i := 1; i < len(fields); i++ {
+		for j := 0; j < i; j++ {
+			if fields[i].Key == fields[j].Key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func snapshot(fields []Field) {
+	eager := LazyMode(lazyMode.Load()) == LazyEager
 	for i := range fields {
+		if eager && fields[i].Value.Type() == valf.TypeStringer {
+			fields[i] = fields[i].Resolve()
+		}
 		valf.Snapshot(&fields[i].Value)
 	}
 }
@@ -33,6 +33,53 @@ func (c mockContext) Value(key interface{}) interface{} {
 	return c.ValueFunc(key)
 }
 
+func TestNewDoesNotInheritFields(t *testing.T) {
+	parent := New(context.Background(), String("request_id", "r1"))
+	ctx := New(parent, String("user", "alice"))
+	assert.Equal(t, []Field{String("user", "alice")}, ctx.Fields())
+}
+
+func TestNewInherited(t *testing.T) {
+	parent := New(context.Background(), String("request_id", "r1"))
+	ctx := NewInherited(parent, String("user", "alice"))
+	assert.Equal(t, []Field{String("request_id", "r1"), String("user", "alice")}, ctx.Fields())
+	assert.Equal(t, []Field{String("request_id", "r1")}, parent.Fields())
+
+	ctx = NewInherited(&parent)
+	assert.Equal(t, []Field{String("request_id", "r1")}, ctx.Fields())
+}
+
+func TestNewInheritedThroughStdContexts(t *testing.T) {
+	var parent context.Context = New(context.Background(), String("request_id", "r1"))
+	parent = context.WithValue(parent, "k", "v")
+	parent, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	ctx := NewInherited(parent, String("user", "alice"))
+	assert.Equal(t, []Field{String("request_id", "r1"), String("user", "alice")}, ctx.Fields())
+	assert.Equal(t, "v", ctx.Value("k"))
+
+	cancel()
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	child := NewInherited(context.WithValue(ctx, "k2", "v2"), Int("attempt", 1))
+	assert.Equal(t, []Field{String("request_id", "r1"), String("user", "alice"), Int("attempt", 1)}, Fields(child))
+	assert.Equal(t, "v", child.Value("k"))
+}
+
+func TestNewIsolated(t *testing.T) {
+	parent := context.WithValue(New(context.Background(), String("request_id", "r1")), "k", "v")
+
+	ctx := NewIsolated(parent, String("user", "alice"))
+	assert.Equal(t, []Field{String("user", "alice")}, ctx.Fields())
+	assert.Equal(t, "v", ctx.Value("k"))
+
+	ctx = NewIsolated(parent)
+	assert.Empty(t, ctx.Fields())
+	assert.Empty(t, Fields(context.WithValue(ctx, "k2", "v2")))
+	assert.Equal(t, []Field{Int("attempt", 1)}, NewInherited(ctx, Int("attempt", 1)).Fields())
+}
+
 func TestContextCreation(t *testing.T) {
 	ctx := New(context.Background(), Bool("bool", true), Int("int", 123))
 	fields := ctx.Fields()
@@ -95,17 +142,30 @@ func TestContextValue(t *testing.T) {
 	ctx := New(c, fields...)
 	actual := ctx.Value(goldenKey)
 	assert.Equal(t, goldenValue, actual)
-	assert.Equal(t, fields, ctx.Value(key{}))
+	assert.Equal(t, fields, ctx.Value(key{}).(*chunk).Fields())
 }
 
 func TestContextWith(t *testing.T) {
 	ctx := New(context.Background())
 	fields := []Field{Bool("bool", true)}
 	ctx = ctx.With(fields...)
-	assert.Equal(t, fields, ctx.Value(key{}))
+	assert.Equal(t, fields, ctx.Value(key{}).(*chunk).Fields())
 	fields = append(fields, Int("int", 42))
 	ctx = ctx.With(Int("int", 42))
-	assert.Equal(t, fields, ctx.Value(key{}))
+	assert.Equal(t, fields, ctx.Value(key{}).(*chunk).Fields())
+}
+
+func TestContextWithCopiesFields(t *testing.T) {
+	ctx := New(context.Background(), Bool("bool", true))
+	fields := []Field{Int("int", 42)}
+	ctx = ctx.With(fields...)
+	fields[0] = Int("int", 0)
+	assert.Equal(t, []Field{Bool("bool", true), Int("int", 42)}, ctx.Fields())
+
+	fields = []Field{Int("int", 42)}
+	ctx = New(context.Background(), fields...)
+	fields[0] = Int("int", 0)
+	assert.Equal(t, []Field{Int("int", 42)}, ctx.Fields())
 }
 
 func TestContextWithDeadline(t *testing.T) {
@@ -139,6 +199,75 @@ func TestContextWithCancel(t *testing.T) {
 	assert.Equal(t, context.Canceled, ctx.Err())
 }
 
+func TestContextWithCancelCause(t *testing.T) {
+	cause := errors.New("shutdown")
+	ctx := New(context.Background(), Bool("bool", true))
+	ctx, cancel := ctx.WithCancelCause()
+	assert.Nil(t, ctx.Cause())
+	cancel(cause)
+	assert.Equal(t, context.Canceled, ctx.Err())
+	assert.Equal(t, cause, ctx.Cause())
+	assert.Equal(t, cause, context.Cause(ctx))
+	assert.Equal(t, []Field{Bool("bool", true)}, ctx.Fields())
+}
+
+func TestContextWithDeadlineCause(t *testing.T) {
+	cause := errors.New("too slow")
+	ctx := New(context.Background(), Bool("bool", true))
+	ctx, cancel := ctx.WithDeadlineCause(time.Now().Add(-time.Second), cause)
+	defer cancel()
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	assert.Equal(t, cause, ctx.Cause())
+}
+
+func TestContextWithTimeoutCause(t *testing.T) {
+	cause := errors.New("too slow")
+	ctx := New(context.Background(), Bool("bool", true))
+	ctx, cancel := ctx.WithTimeoutCause(time.Millisecond, cause)
+	defer cancel()
+	<-ctx.Done()
+	assert.Equal(t, cause, ctx.Cause())
+	assert.Equal(t, []Field{Bool("bool", true)}, ctx.Fields())
+}
+
+func TestContextAfterFunc(t *testing.T) {
+	ctx, cancel := New(context.Background(), Bool("bool", true)).WithCancel()
+	called := make(chan struct{})
+	ctx.AfterFunc(func() {
+		close(called)
+	})
+	cancel()
+	<-called
+
+	stop := New(context.Background()).AfterFunc(func() {})
+	assert.True(t, stop())
+}
+
+func TestContextDetach(t *testing.T) {
+	ctx, cancel := New(context.Background(), Bool("bool", true)).WithValue("key", "value").WithTimeout(time.Second)
+	detached := ctx.Detach()
+	cancel()
+
+	assert.Equal(t, context.Canceled, ctx.Err())
+	assert.Nil(t, detached.Err())
+	assert.Nil(t, detached.Done())
+	_, ok := detached.Deadline()
+	assert.False(t, ok)
+	assert.Equal(t, "value", detached.Value("key"))
+	assert.Equal(t, []Field{Bool("bool", true)}, detached.Fields())
+}
+
+func TestWithoutCancel(t *testing.T) {
+	parent, cancel := context.WithCancel(New(context.Background(), Bool("bool", true)))
+	detached := WithoutCancel(parent)
+	cancel()
+
+	assert.Nil(t, detached.Err())
+	assert.Equal(t, []Field{Bool("bool", true)}, Fields(detached))
+	assert.Nil(t, WithoutCancel(context.Background()).Fields())
+}
+
 func TestContextWithValue(t *testing.T) {
 	ctx := New(context.Background(), Bool("bool", true))
 	ctx = ctx.WithValue("some-value", 42)
@@ -203,6 +332,32 @@ func BenchmarkContextAppending(b *testing.B) {
 		_ = ctx.With(Bool("bool-2", false), Int("int-2", 456))
 	}
 }
+
+func BenchmarkContextAppendingDeep(b *testing.B) {
+	ctx := newDeepContext(context.Background(), benchmarkChainDepth)
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i != b.N; i++ {
+		_ = ctx.With(Bool("bool-2", false), Int("int-2", 456))
+	}
+}
+
+func BenchmarkContextBuildingDeep(b *testing.B) {
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i != b.N; i++ {
+		_ = newDeepContext(context.Background(), benchmarkChainDepth)
+	}
+}
+
+func BenchmarkContextBuildingDeepWithFields(b *testing.B) {
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i != b.N; i++ {
+		_ = newDeepContext(context.Background(), benchmarkChainDepth).Fields()
+	}
+}
+
 func BenchmarkContextDecoding(b *testing.B) {
 	ctx, cancel := context.WithCancel(New(context.Background(), Bool("bool", true), Int("int", 123)))
 	defer cancel()
@@ -213,3 +368,135 @@ func BenchmarkContextDecoding(b *testing.B) {
 		_, _ = Decode(ctx)
 	}
 }
+
+func BenchmarkContextDecodingDeep(b *testing.B) {
+	ctx, cancel := context.WithCancel(newDeepContext(context.Background(), benchmarkChainDepth))
+	defer cancel()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i != b.N; i++ {
+		_, _ = Decode(ctx)
+	}
+}
+
+func BenchmarkContextLookupDeep(b *testing.B) {
+	ctx := newDeepContext(context.Background(), benchmarkChainDepth)
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i != b.N; i++ {
+		_, _ = ctx.Lookup("bool-0")
+	}
+}
+
+const benchmarkChainDepth = 30
+
+func newDeepContext(parent context.Context, depth int) Context {
+	ctx := New(parent, Bool("bool-0", true), Int("int-0", 0))
+	for i := 1; i < depth; i++ {
+		ctx = ctx.With(Bool("bool", true), Int("int", i))
+	}
+
+	return ctx
+}
+
+func TestContextLookup(t *testing.T) {
+	ctx := New(context.Background(), Int("user_id", 1), Bool("bool", true))
+	ctx = ctx.With(Int("user_id", 2))
+
+	field, ok := ctx.Lookup("user_id")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, Int("user_id", 2), field)
+
+	field, ok = ctx.Lookup("bool")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, Bool("bool", true), field)
+
+	_, ok = ctx.Lookup("missing")
+	assert.Equal(t, false, ok)
+}
+
+func TestContextWithout(t *testing.T) {
+	base := New(context.Background(), Int("a", 1), Int("b", 2), Int("a", 3), Int("c", 4))
+
+	ctx := base.Without("a", "missing")
+	assert.Equal(t, []Field{Int("b", 2), Int("c", 4)}, ctx.Fields())
+	assert.Equal(t, 2, cap(ctx.Fields()))
+	assert.Equal(t, []Field{Int("a", 1), Int("b", 2), Int("a", 3), Int("c", 4)}, base.Fields())
+
+	ctx = base.Without("missing")
+	assert.Equal(t, base.Fields(), ctx.Fields())
+}
+
+func TestContextReplace(t *testing.T) {
+	base := New(context.Background(), Int("a", 1), Int("b", 2), Int("a", 3))
+
+	ctx := base.Replace(Int("a", 4), Int("c", 5))
+	assert.Equal(t, []Field{Int("b", 2), Int("a", 4), Int("c", 5)}, ctx.Fields())
+	assert.Equal(t, []Field{Int("a", 1), Int("b", 2), Int("a", 3)}, base.Fields())
+}
+
+func TestContextUniqueFields(t *testing.T) {
+	ctx := New(context.Background(), Int("a", 1), Int("b", 2))
+	assert.Equal(t, ctx.Fields(), ctx.UniqueFields())
+
+	ctx = ctx.With(Int("a", 3), Int("c", 4))
+	assert.Equal(t, []Field{Int("a", 3), Int("b", 2), Int("c", 4)}, ctx.UniqueFields())
+}
+
+func TestUniqueManyFields(t *testing.T) {
+	var fields []Field
+	for i := 0; i != 2*maxLinearScanLength; i++ {
+		fields = append(fields, Int(string(rune('a'+i)), i))
+	}
+	assert.Equal(t, fields, Unique(fields))
+
+	fields = append(fields, Int("a", -1))
+	unique := Unique(fields)
+	require.Len(t, unique, 2*maxLinearScanLength)
+	assert.Equal(t, Int("a", -1), unique[0])
+}
+
+func TestContextDeepChain(t *testing.T) {
+	base := New(context.Background(), Int("int", 0))
+	var expected []Field
+	expected = append(expected, Int("int", 0))
+
+	ctx := base
+	for i := 1; i != 10; i++ {
+		ctx = ctx.With(Int("int", i), Bool("bool", i%2 == 0))
+		expected = append(expected, Int("int", i), Bool("bool", i%2 == 0))
+	}
+
+	assert.Equal(t, expected, ctx.Fields())
+	assert.Equal(t, len(expected), cap(ctx.Fields()))
+	assert.Equal(t, []Field{Int("int", 0)}, base.Fields())
+
+	field, ok := ctx.Lookup("int")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, Int("int", 9), field)
+
+	decoded, ok := Decode(context.WithValue(ctx, "some-key", 42))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, expected, decoded.Fields())
+}
+
+func TestContextBranching(t *testing.T) {
+	base := New(context.Background(), Int("a", 1))
+	ctx1 := base.With(Int("b", 2))
+	ctx2 := base.With(Int("c", 3))
+	ctx3 := ctx1.With()
+
+	assert.Equal(t, []Field{Int("a", 1), Int("b", 2)}, ctx1.Fields())
+	assert.Equal(t, []Field{Int("a", 1), Int("c", 3)}, ctx2.Fields())
+	assert.Equal(t, ctx1.Fields(), ctx3.Fields())
+	assert.Equal(t, []Field{Int("a", 1)}, base.Fields())
+}
+
+func TestContextEmptyChunks(t *testing.T) {
+	ctx := New(context.Background(), []Field{}...).With(Int("a", 1))
+	assert.Equal(t, []Field{Int("a", 1)}, ctx.Fields())
+
+	ctx = ctx.Without("a").With(Int("b", 2))
+	assert.Equal(t, []Field{Int("b", 2)}, ctx.Fields())
+}
@@ -0,0 +1,99 @@
+// Package ctxfgrpc provides gRPC client and server interceptors
+// carrying ctxf fields across RPC calls.
+//
+// Fields are propagated through gRPC metadata using a ctxf.Propagator.
+// If the propagator is nil, the one set by ctxf.SetPropagator is used.
+package ctxfgrpc
+
+import (
+	"context"
+
+	"github.com/pamburus/ctxf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Keys of fields added by server interceptors.
+const (
+	MethodKey = "grpc.method"
+	PeerKey   = "grpc.peer"
+)
+
+func init() {
+	ctxf.ReserveKeys(MethodKey, PeerKey)
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor which adds
+// fields associated with the context to the outgoing metadata.
+func UnaryClientInterceptor(propagator ctxf.Propagator) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(inject(ctx, propagator), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns a grpc.StreamClientInterceptor which adds
+// fields associated with the context to the outgoing metadata.
+func StreamClientInterceptor(propagator ctxf.Propagator) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(inject(ctx, propagator), desc, cc, method, opts...)
+	}
+}
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor which seeds
+// the handler context with fields extracted from the incoming metadata,
+// the full method name and the peer address.
+func UnaryServerInterceptor(propagator ctxf.Propagator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(extract(ctx, propagator, info.FullMethod), req)
+	}
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor which seeds
+// the stream context with fields extracted from the incoming metadata,
+// the full method name and the peer address.
+func StreamServerInterceptor(propagator ctxf.Propagator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ss, extract(ss.Context(), propagator, info.FullMethod)})
+	}
+}
+
+func inject(ctx context.Context, propagator ctxf.Propagator) context.Context {
+	if _, ok := ctxf.Decode(ctx); !ok {
+		return ctx
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	resolve(propagator).Inject(ctx, ctxf.MultiMapCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func extract(ctx context.Context, propagator ctxf.Propagator, method string) ctxf.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	fields := []ctxf.Field{ctxf.String(MethodKey, method)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, ctxf.String(PeerKey, p.Addr.String()))
+	}
+
+	return resolve(propagator).Extract(ctx, ctxf.MultiMapCarrier(md)).With(fields...)
+}
+
+func resolve(propagator ctxf.Propagator) ctxf.Propagator {
+	if propagator != nil {
+		return propagator
+	}
+
+	return ctxf.GetPropagator()
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
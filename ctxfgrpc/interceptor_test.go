@@ -0,0 +1,126 @@
+package ctxfgrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/pamburus/ctxf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type capture struct {
+	fields chan []ctxf.Field
+}
+
+func (c *capture) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	c.fields <- ctxf.Fields(ctx)
+
+	return handler(ctx, req)
+}
+
+func (c *capture) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	c.fields <- ctxf.Fields(ss.Context())
+
+	return handler(srv, ss)
+}
+
+func setup(t *testing.T, propagator ctxf.Propagator) (healthpb.HealthClient, *capture) {
+	t.Helper()
+
+	c := &capture{make(chan []ctxf.Field, 1)}
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor(propagator), c.unary),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(propagator), c.stream),
+	)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(propagator)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(propagator)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return healthpb.NewHealthClient(conn), c
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	client, c := setup(t, ctxf.NewBaggagePropagator("tenant", "user_id"))
+
+	ctx := ctxf.New(context.Background(), ctxf.String("tenant", "acme"), ctxf.Int64("user_id", 42), ctxf.String("secret", "x"))
+	ctx = ctxf.DecodeOptional(metadata.AppendToOutgoingContext(ctx, "baggage", "other=1"))
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	fields := <-c.fields
+	require.Len(t, fields, 4)
+	assert.Equal(t, []ctxf.Field{
+		ctxf.String("tenant", "acme"),
+		ctxf.Int64("user_id", 42),
+		ctxf.String(MethodKey, "/grpc.health.v1.Health/Check"),
+	}, fields[:3])
+	assert.Equal(t, PeerKey, fields[3].Key)
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	client, c := setup(t, ctxf.NewBaggagePropagator("tenant"))
+
+	ctx, cancel := context.WithCancel(ctxf.New(context.Background(), ctxf.String("tenant", "acme")))
+	defer cancel()
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	fields := <-c.fields
+	require.Len(t, fields, 3)
+	assert.Equal(t, []ctxf.Field{
+		ctxf.String("tenant", "acme"),
+		ctxf.String(MethodKey, "/grpc.health.v1.Health/Watch"),
+	}, fields[:2])
+	assert.Equal(t, PeerKey, fields[2].Key)
+}
+
+func TestInterceptorsWithoutFields(t *testing.T) {
+	client, c := setup(t, ctxf.NewBaggagePropagator("tenant"))
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	fields := <-c.fields
+	require.Len(t, fields, 2)
+	assert.Equal(t, ctxf.String(MethodKey, "/grpc.health.v1.Health/Check"), fields[0])
+}
+
+func TestInterceptorsWithDefaultPropagator(t *testing.T) {
+	ctxf.SetPropagator(ctxf.NewBaggagePropagator("tenant"))
+	defer ctxf.SetPropagator(nil)
+
+	client, c := setup(t, nil)
+
+	_, err := client.Check(ctxf.New(context.Background(), ctxf.String("tenant", "acme")), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	fields := <-c.fields
+	require.Len(t, fields, 3)
+	assert.Equal(t, ctxf.String("tenant", "acme"), fields[0])
+}
@@ -0,0 +1,113 @@
+// Package ctxfhttp provides net/http server middleware and client transport
+// carrying ctxf fields across HTTP requests.
+package ctxfhttp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/pamburus/ctxf"
+)
+
+// RequestIDHeader is the name of HTTP header holding request id.
+const RequestIDHeader = "X-Request-Id"
+
+// MaxRequestIDLength is the maximum length of a request id accepted from the X-Request-Id header.
+const MaxRequestIDLength = 128
+
+// Keys of fields added by Handler.
+const (
+	MethodKey     = "http.method"
+	RouteKey      = "http.route"
+	RemoteAddrKey = "http.remote_addr"
+	RequestIDKey  = "request_id"
+)
+
+func init() {
+	ctxf.ReserveKeys(MethodKey, RouteKey, RemoteAddrKey, RequestIDKey)
+}
+
+// Handler is an http.Handler middleware which seeds request context with fields.
+//
+// It extracts fields propagated by the client using Propagator, or the one set by
+// ctxf.SetPropagator if Propagator is nil, and adds fields
+// describing the request: method, route, remote address and request id.
+// The request id is taken from the X-Request-Id header or generated if the header is missing
+// or its value is not a token of at most MaxRequestIDLength ASCII letters, digits, '.', '_' and '-',
+// and is also returned to the client in the X-Request-Id response header.
+type Handler struct {
+	Next       http.Handler
+	Propagator ctxf.Propagator
+
+	// Route returns route of the request. If nil, request URL path is used.
+	Route func(*http.Request) string
+
+	// NewRequestID returns a new request id. If nil, a random 128-bit hex string is used.
+	NewRequestID func() string
+}
+
+// NewHandler returns a new Handler which calls next with the seeded request context.
+func NewHandler(next http.Handler, propagator ctxf.Propagator) *Handler {
+	return &Handler{Next: next, Propagator: propagator}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(RequestIDHeader)
+	if !isValidRequestID(requestID) {
+		requestID = h.newRequestID()
+	}
+	w.Header().Set(RequestIDHeader, requestID)
+
+	ctx := h.propagator().Extract(r.Context(), ctxf.HeaderCarrier(r.Header)).With(
+		ctxf.String(MethodKey, r.Method),
+		ctxf.String(RouteKey, h.route(r)),
+		ctxf.String(RemoteAddrKey, r.RemoteAddr),
+		ctxf.String(RequestIDKey, requestID),
+	)
+
+	h.Next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (h *Handler) propagator() ctxf.Propagator {
+	if h.Propagator != nil {
+		return h.Propagator
+	}
+
+	return ctxf.GetPropagator()
+}
+
+func (h *Handler) route(r *http.Request) string {
+	if h.Route != nil {
+		return h.Route(r)
+	}
+
+	return r.URL.Path
+}
+
+func (h *Handler) newRequestID() string {
+	if h.NewRequestID != nil {
+		return h.NewRequestID()
+	}
+
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+
+	return hex.EncodeToString(id[:])
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > MaxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,145 @@
+package ctxfhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pamburus/ctxf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	var fields []ctxf.Field
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = ctxf.Fields(r.Context())
+	})
+
+	h := NewHandler(next, ctxf.NewBaggagePropagator("tenant"))
+	h.NewRequestID = func() string { return "generated" }
+
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set(ctxf.BaggageHeader, "tenant=acme")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, []ctxf.Field{
+		ctxf.String("tenant", "acme"),
+		ctxf.String(MethodKey, http.MethodGet),
+		ctxf.String(RouteKey, "/users/42"),
+		ctxf.String(RemoteAddrKey, "10.0.0.1:1234"),
+		ctxf.String(RequestIDKey, "generated"),
+	}, fields)
+	assert.Equal(t, "generated", w.Header().Get(RequestIDHeader))
+}
+
+func TestHandlerRequestIDFromHeader(t *testing.T) {
+	var ctx ctxf.Context
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = ctxf.DecodeOptional(r.Context())
+	})
+
+	h := NewHandler(next, ctxf.NewBaggagePropagator())
+	h.Route = func(*http.Request) string { return "/users/{id}" }
+
+	r := httptest.NewRequest(http.MethodPost, "/users/42", nil)
+	r.Header.Set(RequestIDHeader, "r-1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	field, ok := ctx.Lookup(RequestIDKey)
+	require.True(t, ok)
+	assert.Equal(t, ctxf.String(RequestIDKey, "r-1"), field)
+	field, ok = ctx.Lookup(RouteKey)
+	require.True(t, ok)
+	assert.Equal(t, ctxf.String(RouteKey, "/users/{id}"), field)
+	assert.Equal(t, "r-1", w.Header().Get(RequestIDHeader))
+}
+
+func TestHandlerGeneratesRequestID(t *testing.T) {
+	h := NewHandler(http.NotFoundHandler(), ctxf.NewBaggagePropagator())
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	id1 := w.Header().Get(RequestIDHeader)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	id2 := w.Header().Get(RequestIDHeader)
+
+	assert.Len(t, id1, 32)
+	assert.NotEqual(t, id1, id2)
+}
+
+func TestHandlerRejectsInvalidRequestID(t *testing.T) {
+	h := NewHandler(http.NotFoundHandler(), ctxf.NewBaggagePropagator())
+	h.NewRequestID = func() string { return "generated" }
+
+	for _, id := range []string{
+		strings.Repeat("a", MaxRequestIDLength+1),
+		"r-1\nlevel=error",
+		"r 1",
+		"r\"1",
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(RequestIDHeader, id)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, "generated", w.Header().Get(RequestIDHeader), id)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, strings.Repeat("a", MaxRequestIDLength))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, strings.Repeat("a", MaxRequestIDLength), w.Header().Get(RequestIDHeader))
+}
+
+func TestEndToEnd(t *testing.T) {
+	propagator := ctxf.NewBaggagePropagator("tenant", "user_id")
+
+	var backendFields []ctxf.Field
+	backend := httptest.NewServer(NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendFields = ctxf.Fields(r.Context())
+	}), propagator))
+	defer backend.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, propagator)}
+
+	frontend := httptest.NewServer(NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := ctxf.DecodeOptional(r.Context()).With(ctxf.Int64("user_id", 42), ctxf.String("secret", "x"))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL+"/backend", nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}), propagator))
+	defer frontend.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, frontend.URL+"/frontend", nil)
+	require.NoError(t, err)
+	req.Header.Set(ctxf.BaggageHeader, "tenant=acme")
+	req.Header.Set(RequestIDHeader, "r-1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "r-1", resp.Header.Get(RequestIDHeader))
+
+	ctx := ctxf.New(context.Background(), backendFields...)
+	for key, expected := range map[string]ctxf.Field{
+		"tenant":     ctxf.String("tenant", "acme"),
+		"user_id":    ctxf.Int64("user_id", 42),
+		RouteKey:     ctxf.String(RouteKey, "/backend"),
+		RequestIDKey: ctxf.String(RequestIDKey, "r-1"),
+	} {
+		field, ok := ctx.Lookup(key)
+		require.True(t, ok, key)
+		assert.Equal(t, expected, field)
+	}
+	_, ok := ctx.Lookup("secret")
+	assert.False(t, ok)
+}
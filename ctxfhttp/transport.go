@@ -0,0 +1,72 @@
+package ctxfhttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pamburus/ctxf"
+)
+
+// Transport is an http.RoundTripper which injects fields associated with
+// the request context into outgoing requests.
+//
+// Fields are injected using Propagator, or the one set by ctxf.SetPropagator
+// if Propagator is nil. If the context has a string field
+// with RequestIDKey, it is also sent in the X-Request-Id header
+// with the redaction policy set by ctxf.SetRedactionPolicy applied.
+// Sensitive request ids are not sent.
+type Transport struct {
+	Base       http.RoundTripper
+	Propagator ctxf.Propagator
+}
+
+// NewTransport returns a new Transport wrapping base.
+// If base is nil, http.DefaultTransport is used.
+func NewTransport(base http.RoundTripper, propagator ctxf.Propagator) *Transport {
+	return &Transport{Base: base, Propagator: propagator}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, ok := ctxf.Decode(r.Context())
+	if ok {
+		r = r.Clone(r.Context())
+		t.propagator().Inject(ctx, ctxf.HeaderCarrier(r.Header))
+		if r.Header.Get(RequestIDHeader) == "" {
+			if requestID, ok := requestID(ctx); ok {
+				r.Header.Set(RequestIDHeader, requestID)
+			}
+		}
+	}
+
+	return t.base().RoundTrip(r)
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+
+	return http.DefaultTransport
+}
+
+func (t *Transport) propagator() ctxf.Propagator {
+	if t.Propagator != nil {
+		return t.Propagator
+	}
+
+	return ctxf.GetPropagator()
+}
+
+// requestID returns value of the string field with RequestIDKey associated with the ctx
+// prepared with ctxf.Export, so that lazy values are computed and the redaction policy is applied.
+func requestID(ctx ctxf.Context) (string, bool) {
+	field, ok := ctx.Lookup(RequestIDKey)
+	if !ok {
+		return "", false
+	}
+
+	return requestIDKey.Get(ctxf.New(context.Background(), ctxf.Export([]ctxf.Field{field})...))
+}
+
+var requestIDKey = ctxf.NewKey[string](RequestIDKey)
@@ -0,0 +1,83 @@
+package ctxfhttp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pamburus/ctxf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTransport(t *testing.T) {
+	var sent *http.Request
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		sent = r
+
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	ctx := ctxf.New(context.Background(), ctxf.String("tenant", "acme"), ctxf.String(RequestIDKey, "r-1"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+
+	_, err = NewTransport(base, ctxf.NewBaggagePropagator("tenant")).RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant=acme", sent.Header.Get(ctxf.BaggageHeader))
+	assert.Equal(t, "r-1", sent.Header.Get(RequestIDHeader))
+	assert.Empty(t, req.Header, "original request must not be modified")
+}
+
+func TestTransportWithoutFields(t *testing.T) {
+	var sent *http.Request
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		sent = r
+
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+
+	_, err = NewTransport(base, ctxf.NewBaggagePropagator("tenant")).RoundTrip(req)
+	require.NoError(t, err)
+	assert.Same(t, req, sent)
+}
+
+func TestTransportRequestIDExport(t *testing.T) {
+	ctxf.SetRedactionPolicy(ctxf.NewRedactionPolicy(ctxf.RedactionRule{Match: ctxf.MatchKeys(RequestIDKey), Redact: ctxf.Mask("***")}))
+	defer ctxf.SetRedactionPolicy(nil)
+
+	var sent *http.Request
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		sent = r
+
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	transport := NewTransport(base, ctxf.NewBaggagePropagator())
+
+	for _, field := range []ctxf.Field{
+		ctxf.String(RequestIDKey, "r-1"),
+		ctxf.LazyString(RequestIDKey, func() string { return "r-1" }),
+	} {
+		req, err := http.NewRequestWithContext(ctxf.New(context.Background(), field), http.MethodGet, "http://example.com", nil)
+		require.NoError(t, err)
+		_, err = transport.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, "***", sent.Header.Get(RequestIDHeader))
+	}
+
+	ctxf.SetRedactionPolicy(nil)
+	req, err := http.NewRequestWithContext(ctxf.New(context.Background(), ctxf.Secret(RequestIDKey, "r-1")), http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Empty(t, sent.Header.Get(RequestIDHeader))
+}
@@ -0,0 +1,469 @@
+// Package ctxflint provides an analyzer reporting misuse of the ctxf package.
+//
+// The analyzer reports
+//   - Any, Formatter, FormatterRepr and Stringer used where a typed or Const constructor fits better,
+//   - slices passed to field constructors and modified afterwards,
+//   - duplicate keys in a single New, With or Replace call,
+//   - keys not matching the pattern given by the -key-pattern flag,
+//   - ctxf.Context stored in struct fields.
+//
+// Mechanical replacements are offered as suggested fixes.
+package ctxflint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"regexp"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const ctxfPath = "github.com/pamburus/ctxf"
+
+// Analyzer reports misuse of the ctxf package.
+var Analyzer = &analysis.Analyzer{
+	Name:     "ctxflint",
+	Doc:      "report misuse of github.com/pamburus/ctxf",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var keyPattern string
+
+func init() {
+	Analyzer.Flags.StringVar(&keyPattern, "key-pattern", "", "regular expression all constant field keys must match, e.g. ^[a-z][a-z0-9_.]*$")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	var keyRE *regexp.Regexp
+	if keyPattern != "" {
+		var err error
+		keyRE, err = regexp.Compile(keyPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key-pattern: %w", err)
+		}
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	filter := []ast.Node{(*ast.CallExpr)(nil), (*ast.StructType)(nil)}
+	inspect.WithStack(filter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			fn, ok := typeutil.Callee(pass.TypesInfo, n).(*types.Func)
+			if !ok || fn.Pkg() == nil || fn.Pkg().Path() != ctxfPath {
+				return true
+			}
+			if isFieldConstructor(fn) {
+				checkConstructor(pass, n, fn)
+				checkMutation(pass, n, fn, stack)
+				checkKey(pass, keyRE, n.Args[0])
+			}
+			switch fn.Name() {
+			case "New", "NewInherited", "NewIsolated":
+				if len(n.Args) > 1 {
+					checkDuplicates(pass, n, n.Args[1:])
+				}
+			case "With", "Replace":
+				if isContext(fn.Type().(*types.Signature).Recv()) {
+					checkDuplicates(pass, n, n.Args)
+				}
+			case "NewKey":
+				checkKey(pass, keyRE, n.Args[0])
+			}
+		case *ast.StructType:
+			checkStruct(pass, n)
+		}
+
+		return true
+	})
+
+	return nil, nil
+}
+
+// checkConstructor reports Any, Formatter, FormatterRepr and Stringer calls
+// which can be replaced with a typed or Const constructor.
+func checkConstructor(pass *analysis.Pass, call *ast.CallExpr, fn *types.Func) {
+	var value ast.Expr
+	var verb ast.Expr
+	switch fn.Name() {
+	case "Any", "FormatterRepr", "Stringer":
+		value = call.Args[1]
+	case "Formatter":
+		verb = call.Args[1]
+		value = call.Args[2]
+	default:
+		return
+	}
+
+	name := calleeIdent(call)
+	if name == nil {
+		return
+	}
+
+	if typed := typedConstructor(pass.TypesInfo.TypeOf(value)); typed != "" && isTypedFriendly(pass, fn.Name(), verb, value) {
+		edits := []analysis.TextEdit{{Pos: name.Pos(), End: name.End(), NewText: []byte(typed)}}
+		if verb != nil {
+			edits = append(edits, analysis.TextEdit{Pos: verb.Pos(), End: value.Pos()})
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos:     call.Pos(),
+			End:     call.End(),
+			Message: fmt.Sprintf("use ctxf.%s instead of ctxf.%s", typed, fn.Name()),
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message:   fmt.Sprintf("Replace with ctxf.%s", typed),
+				TextEdits: edits,
+			}},
+		})
+
+		return
+	}
+
+	if isImmutable(pass, value) && hasReferenceType(pass.TypesInfo.TypeOf(value)) {
+		replacement := "Const" + fn.Name()
+		pass.Report(analysis.Diagnostic{
+			Pos:     call.Pos(),
+			End:     call.End(),
+			Message: fmt.Sprintf("value passed to ctxf.%s cannot be modified, use ctxf.%s", fn.Name(), replacement),
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message:   fmt.Sprintf("Replace with ctxf.%s", replacement),
+				TextEdits: []analysis.TextEdit{{Pos: name.Pos(), End: name.End(), NewText: []byte(replacement)}},
+			}},
+		})
+	}
+}
+
+// isTypedFriendly reports whether replacing the constructor with a typed one keeps the representation.
+// A fmt.Stringer passed to Any is left as is, since it may be rendered with its String method.
+func isTypedFriendly(pass *analysis.Pass, name string, verb, value ast.Expr) bool {
+	switch name {
+	case "FormatterRepr":
+		return false
+	case "Any":
+		return !isStringer(pass.TypesInfo.TypeOf(value))
+	case "Formatter":
+		tv, ok := pass.TypesInfo.Types[verb]
+
+		return ok && tv.Value != nil && tv.Value.Kind() == constant.String && constant.StringVal(tv.Value) == "%v"
+	default:
+		return true
+	}
+}
+
+// checkMutation reports local slices passed to a field constructor and modified later in the same function.
+func checkMutation(pass *analysis.Pass, call *ast.CallExpr, fn *types.Func, stack []ast.Node) {
+	sig := fn.Type().(*types.Signature)
+	if _, ok := sig.Params().At(1).Type().(*types.Slice); !ok || sig.Variadic() {
+		return
+	}
+	id, ok := astutil.Unparen(call.Args[1]).(*ast.Ident)
+	if !ok {
+		return
+	}
+	v, ok := pass.TypesInfo.Uses[id].(*types.Var)
+	if !ok || v.Parent() == nil || v.Parent() == v.Pkg().Scope() {
+		return
+	}
+
+	body := enclosingBody(stack)
+	if body == nil {
+		return
+	}
+
+	isTarget := func(expr ast.Expr) bool {
+		index, ok := astutil.Unparen(expr).(*ast.IndexExpr)
+		if !ok {
+			return false
+		}
+		id, ok := astutil.Unparen(index.X).(*ast.Ident)
+
+		return ok && pass.TypesInfo.Uses[id] == v
+	}
+
+	var mutation ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		if mutation != nil || n == nil {
+			return false
+		}
+		if n.Pos() < call.End() {
+			return n.End() > call.End()
+		}
+
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if isTarget(lhs) {
+					mutation = n
+				}
+			}
+		case *ast.IncDecStmt:
+			if isTarget(n.X) {
+				mutation = n
+			}
+		case *ast.CallExpr:
+			if id, ok := astutil.Unparen(n.Fun).(*ast.Ident); ok && len(n.Args) != 0 {
+				if builtin, ok := pass.TypesInfo.Uses[id].(*types.Builtin); ok && builtin.Name() == "copy" {
+					if dst, ok := astutil.Unparen(n.Args[0]).(*ast.Ident); ok && pass.TypesInfo.Uses[dst] == v {
+						mutation = n
+					}
+				}
+			}
+		}
+
+		return mutation == nil
+	})
+
+	if mutation != nil {
+		pass.Report(analysis.Diagnostic{
+			Pos:     id.Pos(),
+			End:     id.End(),
+			Message: fmt.Sprintf("slice %s passed to ctxf.%s is modified afterwards", id.Name, fn.Name()),
+			Related: []analysis.RelatedInformation{{Pos: mutation.Pos(), End: mutation.End(), Message: "modified here"}},
+		})
+	}
+}
+
+func enclosingBody(stack []ast.Node) *ast.BlockStmt {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.FuncLit:
+			return n.Body
+		case *ast.FuncDecl:
+			return n.Body
+		}
+	}
+
+	return nil
+}
+
+// checkDuplicates reports fields with the same constant key passed to a single call.
+func checkDuplicates(pass *analysis.Pass, call *ast.CallExpr, args []ast.Expr) {
+	if call.Ellipsis.IsValid() {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		key, ok := fieldKey(pass, arg)
+		if !ok {
+			continue
+		}
+		if seen[key] {
+			pass.Reportf(arg.Pos(), "duplicate key %q", key)
+		}
+		seen[key] = true
+	}
+}
+
+// checkKey reports constant keys not matching the configured pattern.
+func checkKey(pass *analysis.Pass, re *regexp.Regexp, arg ast.Expr) {
+	if re == nil {
+		return
+	}
+
+	key, ok := constantString(pass, arg)
+	if ok && !re.MatchString(key) {
+		pass.Reportf(arg.Pos(), "key %q does not match pattern %q", key, re.String())
+	}
+}
+
+// checkStruct reports struct fields of type ctxf.Context.
+func checkStruct(pass *analysis.Pass, st *ast.StructType) {
+	for _, field := range st.Fields.List {
+		t := pass.TypesInfo.TypeOf(field.Type)
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if isContextType(t) {
+			pass.Reportf(field.Pos(), "ctxf.Context should not be stored in a struct field, pass it as a context.Context argument instead")
+		}
+	}
+}
+
+func isFieldConstructor(fn *types.Func) bool {
+	sig := fn.Type().(*types.Signature)
+	if sig.Recv() != nil || sig.Params().Len() < 2 || sig.Results().Len() != 1 {
+		return false
+	}
+	if basic, ok := sig.Params().At(0).Type().(*types.Basic); !ok || basic.Kind() != types.String {
+		return false
+	}
+	named, ok := sig.Results().At(0).Type().(*types.Named)
+
+	return ok && named.Obj().Name() == "Field" && named.Obj().Pkg() == fn.Pkg()
+}
+
+func isContext(recv *types.Var) bool {
+	return recv != nil && isContextType(recv.Type())
+}
+
+func isContextType(t types.Type) bool {
+	named, ok := t.(*types.Named)
+
+	return ok && named.Obj().Name() == "Context" && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == ctxfPath
+}
+
+func fieldKey(pass *analysis.Pass, arg ast.Expr) (string, bool) {
+	call, ok := astutil.Unparen(arg).(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != ctxfPath || !isFieldConstructor(fn) {
+		return "", false
+	}
+
+	return constantString(pass, call.Args[0])
+}
+
+func constantString(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+
+	return constant.StringVal(tv.Value), true
+}
+
+func calleeIdent(call *ast.CallExpr) *ast.Ident {
+	switch fun := astutil.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		return fun
+	case *ast.SelectorExpr:
+		return fun.Sel
+	default:
+		return nil
+	}
+}
+
+// isImmutable reports whether the expression yields a value nobody else can modify.
+func isImmutable(pass *analysis.Pass, expr ast.Expr) bool {
+	expr = astutil.Unparen(expr)
+	if tv, ok := pass.TypesInfo.Types[expr]; ok && tv.Value != nil {
+		return true
+	}
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = astutil.Unparen(unary.X)
+	}
+	_, ok := expr.(*ast.CompositeLit)
+
+	return ok
+}
+
+// hasReferenceType reports whether values of type t may share memory, so that Const constructors
+// avoid copying them. Other values gain nothing from Const constructors.
+func hasReferenceType(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map:
+		return true
+	default:
+		return false
+	}
+}
+
+// typedConstructor returns name of the typed field constructor accepting values of type t.
+func typedConstructor(t types.Type) string {
+	if t == nil {
+		return ""
+	}
+	t = types.Unalias(t)
+	if types.Identical(t, errorType) {
+		return "NamedError"
+	}
+
+	switch t := t.(type) {
+	case *types.Basic:
+		return basicConstructors[t.Kind()]
+	case *types.Named:
+		return timeConstructor(t, "")
+	case *types.Slice:
+		switch elem := types.Unalias(t.Elem()).(type) {
+		case *types.Basic:
+			return sliceConstructors[elem.Kind()]
+		case *types.Named:
+			return timeConstructor(elem, "s")
+		}
+	}
+
+	return ""
+}
+
+func timeConstructor(t *types.Named, suffix string) string {
+	obj := t.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != "time" {
+		return ""
+	}
+
+	switch obj.Name() {
+	case "Duration":
+		return "Duration" + suffix
+	case "Time":
+		if suffix == "" {
+			return "Time"
+		}
+	}
+
+	return ""
+}
+
+// isStringer reports whether values of type t implement fmt.Stringer.
+func isStringer(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, "String")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), types.Typ[types.String])
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+var basicConstructors = map[types.BasicKind]string{
+	types.Bool:    "Bool",
+	types.Int:     "Int",
+	types.Int8:    "Int8",
+	types.Int16:   "Int16",
+	types.Int32:   "Int32",
+	types.Int64:   "Int64",
+	types.Uint:    "Uint",
+	types.Uint8:   "Uint8",
+	types.Uint16:  "Uint16",
+	types.Uint32:  "Uint32",
+	types.Uint64:  "Uint64",
+	types.Float32: "Float32",
+	types.Float64: "Float64",
+	types.String:  "String",
+}
+
+var sliceConstructors = map[types.BasicKind]string{
+	types.Bool:    "Bools",
+	types.Int:     "Ints",
+	types.Int8:    "Ints8",
+	types.Int16:   "Ints16",
+	types.Int32:   "Ints32",
+	types.Int64:   "Ints64",
+	types.Uint:    "Uints",
+	types.Uint8:   "Bytes",
+	types.Uint16:  "Uints16",
+	types.Uint32:  "Uints32",
+	types.Uint64:  "Uints64",
+	types.Float32: "Floats32",
+	types.Float64: "Floats64",
+	types.String:  "Strings",
+}
@@ -0,0 +1,21 @@
+package ctxflint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "a")
+}
+
+func TestAnalyzerKeyPattern(t *testing.T) {
+	require.NoError(t, Analyzer.Flags.Set("key-pattern", `^[a-z][a-z0-9_.]*$`))
+	defer func() {
+		require.NoError(t, Analyzer.Flags.Set("key-pattern", ""))
+	}()
+
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "keys")
+}
@@ -0,0 +1,80 @@
+package a
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pamburus/ctxf"
+)
+
+type point struct{ X, Y int }
+
+type id int
+
+type dur = time.Duration
+
+func constructors(ctx context.Context, n int64, s string, d time.Duration, da dur, ss []string, p point) {
+	err := errors.New("failed")
+	_ = ctxf.New(ctx,
+		ctxf.Any("n", n),                      // want `use ctxf.Int64 instead of ctxf.Any`
+		ctxf.Any("s", s),                      // want `use ctxf.String instead of ctxf.Any`
+		ctxf.Any("ss", ss),                    // want `use ctxf.Strings instead of ctxf.Any`
+		ctxf.Any("err", err),                  // want `use ctxf.NamedError instead of ctxf.Any`
+		ctxf.Any("c", 42),                     // want `use ctxf.Int instead of ctxf.Any`
+		ctxf.Stringer("d", d),                 // want `use ctxf.Duration instead of ctxf.Stringer`
+		ctxf.Stringer("da", da),               // want `use ctxf.Duration instead of ctxf.Stringer`
+		ctxf.Any("ad", d),                     // ok, a fmt.Stringer
+		ctxf.Formatter("f", "%v", 1.5),        // want `use ctxf.Float64 instead of ctxf.Formatter`
+		ctxf.Formatter("g", "%x", s),          // ok, formatting differs
+		ctxf.Formatter("h", "%x", 255),        // ok, a value type
+		ctxf.Any("p", point{1, 2}),            // ok, a value type
+		ctxf.FormatterRepr("q", &point{}),     // want `value passed to ctxf.FormatterRepr cannot be modified, use ctxf.ConstFormatterRepr`
+		ctxf.Any("r", p),                      // ok
+		ctxf.Any("id", id(1)),                 // ok, a value type
+		ctxf.Any("m", map[string]int{"a": 1}), // want `value passed to ctxf.Any cannot be modified, use ctxf.ConstAny`
+	)
+}
+
+func mutation(ctx context.Context, buf []byte, values []int) {
+	data := []byte("data")
+	fields := []ctxf.Field{
+		ctxf.Bytes("data", data),    // want `slice data passed to ctxf.Bytes is modified afterwards`
+		ctxf.ConstBytes("buf", buf), // want `slice buf passed to ctxf.ConstBytes is modified afterwards`
+		ctxf.Ints("values", values), // want `slice values passed to ctxf.Ints is modified afterwards`
+	}
+	data[0] = 'D'
+	copy(buf, "xyz")
+	func() {
+		values[0]++
+	}()
+	_ = ctxf.New(ctx, fields...)
+
+	other := []byte("other")
+	other[0] = 'O'
+	_ = ctxf.New(ctx, ctxf.Bytes("other", other))
+}
+
+func duplicates(ctx context.Context) {
+	const key = "b"
+	c := ctxf.New(ctx,
+		ctxf.String("a", "1"),
+		ctxf.Int("b", 2),
+		ctxf.String(key, "3"), // want `duplicate key "b"`
+	)
+	c = c.With(ctxf.Int("x", 1), ctxf.Int("x", 2)) // want `duplicate key "x"`
+	c = c.Replace(ctxf.Int("x", 1), ctxf.Int("y", 2))
+	_ = c
+	_ = ctxf.NewInherited(ctx, ctxf.Int("x", 1), ctxf.Int("x", 2)) // want `duplicate key "x"`
+	_ = ctxf.NewIsolated(ctx, ctxf.Int("x", 1), ctxf.Int("x", 2))  // want `duplicate key "x"`
+}
+
+type holder struct {
+	ctx   ctxf.Context  // want `ctxf.Context should not be stored in a struct field`
+	ptr   *ctxf.Context // want `ctxf.Context should not be stored in a struct field`
+	plain context.Context
+}
+
+type embedded struct {
+	ctxf.Context // want `ctxf.Context should not be stored in a struct field`
+}
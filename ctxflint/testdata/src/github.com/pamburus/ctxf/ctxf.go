@@ -0,0 +1,51 @@
+// Package ctxf is a minimal stub of github.com/pamburus/ctxf used by analyzer tests.
+package ctxf
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+type Context struct {
+	context.Context
+}
+
+func New(ctx context.Context, fields ...Field) Context { return Context{ctx} }
+
+func NewInherited(ctx context.Context, fields ...Field) Context { return Context{ctx} }
+
+func NewIsolated(ctx context.Context, fields ...Field) Context { return Context{ctx} }
+
+func (c Context) With(fields ...Field) Context    { return c }
+func (c Context) Replace(fields ...Field) Context { return c }
+
+type Key[T any] struct{ name string }
+
+func NewKey[T any](name string) Key[T] { return Key[T]{name} }
+
+func Bool(k string, v bool) Field                               { return Field{k, v} }
+func Int(k string, v int) Field                                 { return Field{k, v} }
+func Int64(k string, v int64) Field                             { return Field{k, v} }
+func Float64(k string, v float64) Field                         { return Field{k, v} }
+func String(k string, v string) Field                           { return Field{k, v} }
+func Duration(k string, v time.Duration) Field                  { return Field{k, v} }
+func Time(k string, v time.Time) Field                          { return Field{k, v} }
+func Bytes(k string, v []byte) Field                            { return Field{k, v} }
+func ConstBytes(k string, v []byte) Field                       { return Field{k, v} }
+func Ints(k string, v []int) Field                              { return Field{k, v} }
+func Strings(k string, v []string) Field                        { return Field{k, v} }
+func NamedError(k string, v error) Field                        { return Field{k, v} }
+func Stringer(k string, v fmt.Stringer) Field                   { return Field{k, v} }
+func ConstStringer(k string, v fmt.Stringer) Field              { return Field{k, v} }
+func Formatter(k string, verb string, v interface{}) Field      { return Field{k, v} }
+func ConstFormatter(k string, verb string, v interface{}) Field { return Field{k, v} }
+func FormatterRepr(k string, v interface{}) Field               { return Field{k, v} }
+func ConstFormatterRepr(k string, v interface{}) Field          { return Field{k, v} }
+func Any(k string, v interface{}) Field                         { return Field{k, v} }
+func ConstAny(k string, v interface{}) Field                    { return Field{k, v} }
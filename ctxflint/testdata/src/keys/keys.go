@@ -0,0 +1,18 @@
+package keys
+
+import (
+	"context"
+
+	"github.com/pamburus/ctxf"
+)
+
+var userID = ctxf.NewKey[int64]("userID") // want `key "userID" does not match pattern`
+
+func keys(ctx context.Context, dynamic string) {
+	_ = ctxf.New(ctx,
+		ctxf.String("http.method", "GET"),
+		ctxf.Int("request_id", 1),
+		ctxf.Int("RequestID", 1), // want `key "RequestID" does not match pattern`
+		ctxf.String(dynamic, "x"),
+	)
+}
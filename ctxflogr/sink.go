@@ -0,0 +1,223 @@
+// Package ctxflogr provides a github.com/go-logr/logr sink backed by ctxf fields
+// and a bridge adding fields associated with a context to logr loggers.
+package ctxflogr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pamburus/ctxf"
+)
+
+// Entry is a log entry produced by LogSink.
+type Entry struct {
+	Time    time.Time
+	Name    string
+	Level   int
+	Message string
+
+	// IsError reports whether the entry was logged with logr.Logger.Error.
+	IsError bool
+	Err     error
+
+	// Fields holds values added with logr.Logger.WithValues followed by
+	// values passed with the entry. Fields with duplicate keys are reduced to the last value.
+	// The redaction policy is not applied to them, so that Writers apply it exactly once
+	// with ctxf.Export, as the encoders used by EncoderWriter do.
+	Fields []ctxf.Field
+}
+
+// Writer writes entries produced by LogSink.
+// It must be safe for concurrent use.
+type Writer interface {
+	Write(entry Entry)
+}
+
+// LogSink is a logr.LogSink which stores values as ctxf fields
+// and passes entries to a Writer.
+type LogSink struct {
+	writer    Writer
+	verbosity int
+	name      string
+	fields    []ctxf.Field
+}
+
+// NewLogSink returns a new LogSink passing entries with levels up to the verbosity to the writer.
+func NewLogSink(writer Writer, verbosity int) *LogSink {
+	return &LogSink{writer: writer, verbosity: verbosity}
+}
+
+// New returns a new logr.Logger backed by LogSink, see NewLogSink for details.
+func New(writer Writer, verbosity int) logr.Logger {
+	return logr.New(NewLogSink(writer, verbosity))
+}
+
+// Init implements logr.LogSink.
+func (s *LogSink) Init(logr.RuntimeInfo) {}
+
+// Enabled reports whether the level does not exceed the verbosity of the LogSink.
+func (s *LogSink) Enabled(level int) bool {
+	return level <= s.verbosity
+}
+
+// Info writes an entry with the given level, message and values.
+func (s *LogSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.writer.Write(Entry{
+		Time:    time.Now(),
+		Name:    s.name,
+		Level:   level,
+		Message: msg,
+		Fields:  s.entryFields(Fields(keysAndValues...)),
+	})
+}
+
+// Error writes an error entry with the given error, message and values.
+func (s *LogSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.writer.Write(Entry{
+		Time:    time.Now(),
+		Name:    s.name,
+		Message: msg,
+		IsError: true,
+		Err:     err,
+		Fields:  s.entryFields(Fields(keysAndValues...)),
+	})
+}
+
+// WithValues returns a new LogSink with values converted to fields, see Fields for details.
+func (s *LogSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return s.with(Fields(keysAndValues...))
+}
+
+// WithName returns a new LogSink with the name appended to its name using "/" as a separator.
+func (s *LogSink) WithName(name string) logr.LogSink {
+	result := *s
+	if result.name != "" {
+		result.name += "/" + name
+	} else {
+		result.name = name
+	}
+
+	return &result
+}
+
+var _ logr.LogSink = (*LogSink)(nil)
+
+func (s *LogSink) with(fields []ctxf.Field) *LogSink {
+	result := *s
+	result.fields = make([]ctxf.Field, len(s.fields), len(s.fields)+len(fields))
+	copy(result.fields, s.fields)
+	for i := range fields {
+		result.fields = append(result.fields, fields[i].Snapshot())
+	}
+
+	return &result
+}
+
+func (s *LogSink) entryFields(fields []ctxf.Field) []ctxf.Field {
+	if len(s.fields) != 0 {
+		fields = append(s.fields[:len(s.fields):len(s.fields)], fields...)
+	}
+
+	return ctxf.Unique(fields)
+}
+
+// Fields converts logr key/value pairs to fields.
+//
+// Keys which are not strings are formatted with fmt.Sprint. A key missing its value gets a nil value.
+// Values of common types are converted to fields of the matching kinds directly,
+// values implementing logr.Marshaler are replaced with the result of MarshalLog,
+// other values are converted with ctxf.Any.
+func Fields(keysAndValues ...interface{}) []ctxf.Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+
+	result := make([]ctxf.Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		var value interface{}
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		result = append(result, field(key, value))
+	}
+
+	return result
+}
+
+func field(key string, value interface{}) ctxf.Field {
+	switch v := value.(type) {
+	case string:
+		return ctxf.String(key, v)
+	case int:
+		return ctxf.Int(key, v)
+	case int64:
+		return ctxf.Int64(key, v)
+	case bool:
+		return ctxf.Bool(key, v)
+	case float64:
+		return ctxf.Float64(key, v)
+	case time.Duration:
+		return ctxf.Duration(key, v)
+	case time.Time:
+		return ctxf.Time(key, v)
+	case logr.Marshaler:
+		return ctxf.Any(key, v.MarshalLog())
+	case error:
+		return ctxf.NamedError(key, v)
+	default:
+		return ctxf.Any(key, v)
+	}
+}
+
+// FromContext returns the logger stored in the ctx with logr.NewContext,
+// or a logger discarding all entries if there is none,
+// with fields associated with the ctx added as values, see WithFields for details.
+func FromContext(ctx context.Context) logr.Logger {
+	return WithFields(logr.FromContextOrDiscard(ctx), ctxf.Fields(ctx))
+}
+
+// WithFields returns the logger with fields added as values.
+//
+// If the logger is backed by LogSink, the fields are added as is.
+// Otherwise they are converted to plain Go values: scalars to the values of slog.Value.Any,
+// groups, objects and arrays to map[string]interface{}.
+// Fields with duplicate keys are reduced to the last value
+// and the redaction policy set by ctxf.SetRedactionPolicy is applied to the fields.
+func WithFields(logger logr.Logger, fields []ctxf.Field) logr.Logger {
+	if len(fields) == 0 {
+		return logger
+	}
+
+	if sink, ok := logger.GetSink().(*LogSink); ok {
+		return logger.WithSink(sink.with(fields))
+	}
+
+	fields = ctxf.Export(ctxf.Unique(fields))
+	keysAndValues := make([]interface{}, 0, 2*len(fields))
+	for i := range fields {
+		keysAndValues = append(keysAndValues, fields[i].Key, plain(ctxf.SlogValue(fields[i].Value)))
+	}
+
+	return logger.WithValues(keysAndValues...)
+}
+
+func plain(v slog.Value) interface{} {
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+
+	attrs := v.Group()
+	result := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		result[attr.Key] = plain(attr.Value)
+	}
+
+	return result
+}
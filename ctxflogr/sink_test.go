@@ -0,0 +1,143 @@
+package ctxflogr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/pamburus/ctxf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capture struct {
+	entries []Entry
+}
+
+func (c *capture) Write(entry Entry) {
+	c.entries = append(c.entries, entry)
+}
+
+type marshaler struct{}
+
+func (marshaler) MarshalLog() interface{} {
+	return "marshaled"
+}
+
+func TestLogSink(t *testing.T) {
+	var c capture
+	logger := New(&c, 1).WithName("a").WithName("b")
+
+	ids := []int{1, 2}
+	logger = logger.WithValues("user", "alice", "ids", ids)
+	ids[0] = 0
+
+	logger.V(1).Info("hello", "n", 1, "m", marshaler{})
+	logger.V(2).Info("skipped")
+	logger.Error(errors.New("boom"), "failed", 42, "odd")
+
+	require.Len(t, c.entries, 2)
+
+	assert.Equal(t, "a/b", c.entries[0].Name)
+	assert.Equal(t, 1, c.entries[0].Level)
+	assert.Equal(t, "hello", c.entries[0].Message)
+	assert.False(t, c.entries[0].IsError)
+	assert.Equal(t, []ctxf.Field{
+		ctxf.String("user", "alice"),
+		ctxf.ConstInts("ids", []int{1, 2}),
+		ctxf.Int("n", 1),
+		ctxf.String("m", "marshaled"),
+	}, c.entries[0].Fields)
+
+	assert.True(t, c.entries[1].IsError)
+	assert.EqualError(t, c.entries[1].Err, "boom")
+	assert.Equal(t, []ctxf.Field{
+		ctxf.String("user", "alice"),
+		ctxf.ConstInts("ids", []int{1, 2}),
+		ctxf.String("42", "odd"),
+	}, c.entries[1].Fields)
+}
+
+func TestLogSinkUnique(t *testing.T) {
+	var c capture
+	logger := New(&c, 0).WithValues("email", "joe@example.com", "n", 1)
+	logger.Info("hello", "n", 2)
+
+	require.Len(t, c.entries, 1)
+	assert.Equal(t, []ctxf.Field{
+		ctxf.String("email", "joe@example.com"),
+		ctxf.Int("n", 2),
+	}, c.entries[0].Fields)
+}
+
+func TestFields(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := errors.New("boom")
+
+	assert.Equal(t, []ctxf.Field{
+		ctxf.String("string", "s"),
+		ctxf.Int("int", 1),
+		ctxf.Int64("int64", 2),
+		ctxf.Bool("bool", true),
+		ctxf.Float64("float64", 0.5),
+		ctxf.Duration("duration", time.Second),
+		ctxf.Time("time", ts),
+		ctxf.NamedError("error", err),
+		ctxf.Uint8("uint8", 8),
+		ctxf.Any("missing", nil),
+	}, Fields(
+		"string", "s",
+		"int", 1,
+		"int64", int64(2),
+		"bool", true,
+		"float64", 0.5,
+		"duration", time.Second,
+		"time", ts,
+		"error", err,
+		"uint8", uint8(8),
+		"missing",
+	))
+}
+
+func TestFromContext(t *testing.T) {
+	var c capture
+	ctx := logr.NewContext(context.Background(), New(&c, 0))
+	ctx = ctxf.New(ctx, ctxf.String("request_id", "r1"))
+
+	FromContext(ctx).Info("hello", "n", 1)
+
+	require.Len(t, c.entries, 1)
+	assert.Equal(t, []ctxf.Field{
+		ctxf.String("request_id", "r1"),
+		ctxf.Int("n", 1),
+	}, c.entries[0].Fields)
+}
+
+func TestFromContextForeignSink(t *testing.T) {
+	var lines []string
+	logger := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+
+	ctx := logr.NewContext(context.Background(), logger)
+	ctx = ctxf.New(ctx,
+		ctxf.String("request_id", "r1"),
+		ctxf.Secret("token", "t0k3n"),
+		ctxf.Group("http", ctxf.String("method", "GET")),
+	)
+
+	FromContext(ctx).Info("hello")
+
+	require.Len(t, lines, 1)
+	assert.Equal(t, `"level"=0 "msg"="hello" "request_id"="r1" "token"="[REDACTED]" "http"={"method"="GET"}`, lines[0])
+}
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	ctx := ctxf.New(context.Background(), ctxf.String("request_id", "r1"))
+	assert.NotPanics(t, func() {
+		FromContext(ctx).Info("hello")
+	})
+}
@@ -0,0 +1,78 @@
+package ctxflogr
+
+import (
+	"io"
+	"sync"
+
+	"github.com/pamburus/ctxf"
+)
+
+// Keys of fields added by EncoderWriter.
+const (
+	TimeKey      = "ts"
+	LevelKey     = "level"
+	VerbosityKey = "v"
+	LoggerKey    = "logger"
+	MessageKey   = "msg"
+	ErrorKey     = "error"
+)
+
+// Encoder appends encoded fields to a buffer.
+// It is implemented by encoders of the json and logfmt subpackages of ctxf.
+type Encoder interface {
+	AppendFields(buf []byte, fields []ctxf.Field) []byte
+}
+
+// EncoderWriter is a Writer which encodes each entry with an Encoder as a single line.
+//
+// The entry is encoded as fields with time, level "info" or "error", verbosity of
+// info entries, logger name if it is not empty, message and error of error entries,
+// followed by the fields of the entry. Fields of the entry take precedence over them.
+// The encoder applies the redaction policy set by ctxf.SetRedactionPolicy to all of them.
+type EncoderWriter struct {
+	out     io.Writer
+	encoder Encoder
+	mu      sync.Mutex
+	buf     []byte
+}
+
+// NewEncoderWriter returns a new EncoderWriter writing entries encoded with the encoder to the out.
+func NewEncoderWriter(out io.Writer, encoder Encoder) *EncoderWriter {
+	return &EncoderWriter{out: out, encoder: encoder}
+}
+
+// Write encodes the entry and writes it to the output.
+// Errors returned by the output are ignored.
+func (w *EncoderWriter) Write(entry Entry) {
+	fields := make([]ctxf.Field, 0, 6+len(entry.Fields))
+	fields = append(fields, ctxf.Time(TimeKey, entry.Time))
+	if entry.IsError {
+		fields = append(fields, ctxf.String(LevelKey, "error"))
+	} else {
+		fields = append(fields, ctxf.String(LevelKey, "info"), ctxf.Int(VerbosityKey, entry.Level))
+	}
+	if entry.Name != "" {
+		fields = append(fields, ctxf.String(LoggerKey, entry.Name))
+	}
+	fields = append(fields, ctxf.String(MessageKey, entry.Message))
+	if entry.IsError && entry.Err != nil {
+		fields = append(fields, ctxf.NamedError(ErrorKey, entry.Err))
+	}
+	fields = append(fields, entry.Fields...)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.encoder.AppendFields(w.buf[:0], fields), '\n')
+	_, _ = w.out.Write(w.buf)
+}
+
+var _ Writer = (*EncoderWriter)(nil)
+
+// WriterFunc is a function implementing Writer.
+type WriterFunc func(Entry)
+
+// Write calls f(entry).
+func (f WriterFunc) Write(entry Entry) {
+	f(entry)
+}
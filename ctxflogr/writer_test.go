@@ -0,0 +1,54 @@
+package ctxflogr
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pamburus/ctxf"
+	"github.com/pamburus/ctxf/encoding/json"
+	"github.com/pamburus/ctxf/encoding/logfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoderWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewEncoderWriter(&buf, &json.Encoder{})
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	w.Write(Entry{Time: ts, Level: 1, Name: "a", Message: "hello", Fields: []ctxf.Field{ctxf.Int("n", 1)}})
+	w.Write(Entry{Time: ts, Message: "failed", IsError: true, Err: errors.New("boom"), Fields: []ctxf.Field{ctxf.Secret("token", "t0k3n")}})
+
+	assert.Equal(t,
+		`{"ts":"2020-01-02T03:04:05Z","level":"info","v":1,"logger":"a","msg":"hello","n":1}`+"\n"+
+			`{"ts":"2020-01-02T03:04:05Z","level":"error","msg":"failed","error":"boom","token":"[REDACTED]"}`+"\n",
+		buf.String(),
+	)
+}
+
+func TestEncoderWriterRedactsOnce(t *testing.T) {
+	ctxf.SetRedactionPolicy(ctxf.NewRedactionPolicy(ctxf.RedactionRule{Match: ctxf.MatchKeys("email"), Redact: ctxf.Hash([]byte("k"))}))
+	defer ctxf.SetRedactionPolicy(nil)
+
+	var buf bytes.Buffer
+	logger := New(NewEncoderWriter(&buf, &json.Encoder{}), 0)
+	logger.WithValues("email", "joe@example.com").Info("hello")
+
+	var entry map[string]interface{}
+	require.NoError(t, stdjson.Unmarshal(buf.Bytes(), &entry))
+	var expected map[string]interface{}
+	require.NoError(t, stdjson.Unmarshal(json.AppendJSON(nil, []ctxf.Field{ctxf.String("email", "joe@example.com")}), &expected))
+	assert.Equal(t, expected["email"], entry["email"])
+}
+
+func TestEncoderWriterLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(NewEncoderWriter(&buf, &logfmt.Encoder{}), 0)
+
+	logger.WithValues("user", "alice").Info("hello world")
+
+	assert.Regexp(t, `^ts=\S+ level=info v=0 msg="hello world" user=alice\n$`, buf.String())
+}
@@ -0,0 +1,267 @@
+// Package ctxflogrus provides interoperability between ctxf fields and github.com/sirupsen/logrus.
+package ctxflogrus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pamburus/ctxf"
+	"github.com/pamburus/ctxf/internal/visit"
+	"github.com/pamburus/valf"
+	"github.com/sirupsen/logrus"
+)
+
+// Fields converts fields to logrus.Fields.
+//
+// Scalar values are converted to the Go values of the matching types, except stringers
+// and formatters which are converted to strings. Arrays are converted to []interface{}
+// and objects to nested logrus.Fields. Errors nested in arrays and objects are converted
+// to their messages because logrus formatters only handle errors on the top level.
+//
+// Fields with duplicate keys are reduced to the last value.
+// The redaction policy set by ctxf.SetRedactionPolicy is applied to the fields.
+func Fields(fields []ctxf.Field) logrus.Fields {
+	fields = ctxf.Export(ctxf.Unique(fields))
+	result := make(logrus.Fields, len(fields))
+	for i := range fields {
+		result[fields[i].Key] = convert(fields[i].Value, false)
+	}
+
+	return result
+}
+
+// Hook is a logrus.Hook which adds fields associated with the context
+// of the entry, set with logrus.Entry.WithContext, to the entry.
+// Data of the entry takes precedence over fields with the same keys.
+type Hook struct{}
+
+// Levels implements logrus.Hook, Hook fires for all levels.
+func (Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (Hook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	fields := ctxf.Fields(entry.Context)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	if entry.Data == nil {
+		entry.Data = make(logrus.Fields, len(fields))
+	}
+	for key, value := range Fields(fields) {
+		if _, ok := entry.Data[key]; !ok {
+			entry.Data[key] = value
+		}
+	}
+
+	return nil
+}
+
+var _ logrus.Hook = Hook{}
+
+func convert(v valf.Value, nested bool) interface{} {
+	visitor := valueVisitor{nested: nested}
+	v.AcceptVisitor(&visitor)
+
+	return visitor.value
+}
+
+type valueVisitor struct {
+	nested bool
+	value  interface{}
+}
+
+func (v *valueVisitor) VisitNone() {
+	v.value = nil
+}
+
+func (v *valueVisitor) VisitAny(value interface{}) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitBool(value bool) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitInt(value int) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitInt8(value int8) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitInt16(value int16) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitInt32(value int32) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitInt64(value int64) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitUint(value uint) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitUint8(value uint8) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitUint16(value uint16) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitUint32(value uint32) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitUint64(value uint64) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitFloat32(value float32) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitFloat64(value float64) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitDuration(value time.Duration) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitTime(value time.Time) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitError(value error) {
+	switch {
+	case value == nil:
+		v.value = nil
+	case v.nested:
+		v.value = value.Error()
+	default:
+		v.value = value
+	}
+}
+
+func (v *valueVisitor) VisitString(value string) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitStringer(value fmt.Stringer) {
+	if value == nil {
+		v.value = nil
+
+		return
+	}
+
+	v.value = value.String()
+}
+
+func (v *valueVisitor) VisitFormatter(verb string, value interface{}) {
+	v.value = fmt.Sprintf(verb, value)
+}
+
+func (v *valueVisitor) VisitBytes(value []byte) {
+	v.value = value
+}
+
+func (v *valueVisitor) VisitBools(values []bool) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitInts(values []int) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitInts8(values []int8) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitInts16(values []int16) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitInts32(values []int32) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitInts64(values []int64) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitUints(values []uint) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitUints8(values []uint8) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitUints16(values []uint16) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitUints32(values []uint32) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitUints64(values []uint64) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitFloats32(values []float32) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitFloats64(values []float64) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitDurations(values []time.Duration) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitStrings(values []string) {
+	v.value = values
+}
+
+func (v *valueVisitor) VisitArray(value valf.ValueArray) {
+	if value == nil {
+		v.value = nil
+
+		return
+	}
+
+	items := []interface{}{}
+	value.AcceptArrayVisitor(visit.ArrayFunc(func(item valf.Value) {
+		items = append(items, convert(item, true))
+	}))
+	v.value = items
+}
+
+func (v *valueVisitor) VisitObject(value valf.ValueObject) {
+	if value == nil {
+		v.value = nil
+
+		return
+	}
+
+	fields := logrus.Fields{}
+	value.AcceptObjectVisitor(visit.ObjectFunc(func(key string, item valf.Value) {
+		fields[key] = convert(item, true)
+	}))
+	v.value = fields
+}
@@ -0,0 +1,67 @@
+package ctxflogrus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pamburus/ctxf"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFields(t *testing.T) {
+	err := errors.New("boom")
+
+	assert.Equal(t, logrus.Fields{
+		"bool":      true,
+		"int":       2,
+		"uint8":     uint8(8),
+		"float64":   0.5,
+		"duration":  time.Second,
+		"string":    "s",
+		"error":     err,
+		"formatter": "0x2a",
+		"token":     ctxf.Redacted,
+		"ints":      []int{1, 2},
+		"none":      nil,
+		"http": logrus.Fields{
+			"method": "GET",
+			"error":  "boom",
+		},
+	}, Fields([]ctxf.Field{
+		ctxf.Bool("bool", true),
+		ctxf.Int("int", 1),
+		ctxf.Int("int", 2),
+		ctxf.Uint8("uint8", 8),
+		ctxf.Float64("float64", 0.5),
+		ctxf.Duration("duration", time.Second),
+		ctxf.String("string", "s"),
+		ctxf.NamedError("error", err),
+		ctxf.Formatter("formatter", "%#x", 42),
+		ctxf.Secret("token", "t0k3n"),
+		ctxf.Ints("ints", []int{1, 2}),
+		ctxf.Any("none", nil),
+		ctxf.Group("http",
+			ctxf.String("method", "GET"),
+			ctxf.NamedError("error", err),
+		),
+	}))
+}
+
+func TestHook(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.AddHook(Hook{})
+
+	ctx := ctxf.New(context.Background(), ctxf.String("user", "alice"), ctxf.String("op", "ctx"))
+	logger.WithContext(ctx).WithField("op", "entry").Info("hello")
+	logger.Info("no context")
+
+	entries := hook.AllEntries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, logrus.Fields{"user": "alice", "op": "entry"}, entries[0].Data)
+	assert.Empty(t, entries[1].Data)
+}
@@ -0,0 +1,91 @@
+package ctxfzap
+
+import (
+	"context"
+
+	"github.com/pamburus/ctxf"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Core is a zapcore.Core wrapping another core which adds fields associated
+// with a context to each entry written through it.
+type Core struct {
+	ctx   context.Context
+	inner zapcore.Core
+}
+
+// NewCore returns a new Core wrapping the inner core which adds fields associated
+// with the ctx to each entry.
+//
+// The fields are added to the inner core with With once, so they are encoded once
+// and not on each entry. Fields with duplicate keys are reduced to the last value.
+// The redaction policy set by ctxf.SetRedactionPolicy is applied to the fields.
+func NewCore(ctx context.Context, inner zapcore.Core) *Core {
+	if fields := Fields(ctxf.Unique(ctxf.Fields(ctx))); len(fields) != 0 {
+		inner = inner.With(fields)
+	}
+
+	return &Core{ctx, inner}
+}
+
+// Context returns the context which fields are added to entries.
+func (c *Core) Context() context.Context {
+	return c.ctx
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (c *Core) Enabled(level zapcore.Level) bool {
+	return c.inner.Enabled(level)
+}
+
+// Level returns the minimum enabled level of the inner core.
+func (c *Core) Level() zapcore.Level {
+	return zapcore.LevelOf(c.inner)
+}
+
+// With returns a new Core with the fields added to the inner core.
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	return &Core{c.ctx, c.inner.With(fields)}
+}
+
+// Check delegates the decision whether the entry should be logged to the inner core,
+// which adds itself with the context fields to the ce if the entry is enabled.
+func (c *Core) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.inner.Check(entry, ce)
+}
+
+// Write writes the entry with the context fields followed by the given fields.
+func (c *Core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.inner.Write(entry, fields)
+}
+
+// Sync flushes the inner core.
+func (c *Core) Sync() error {
+	return c.inner.Sync()
+}
+
+var _ zapcore.Core = (*Core)(nil)
+
+// WithLogger returns a Context carrying the logger which is used by Logger.
+func WithLogger(ctx context.Context, logger *zap.Logger) ctxf.Context {
+	return ctxf.DecodeOptional(ctx).WithValue(loggerKey{}, logger)
+}
+
+// Logger returns the logger carried by the ctx, or the global logger if there is none,
+// with fields associated with the ctx added to each entry, see NewCore for details.
+//
+// Logger is meant to be called once per context, e.g. per request,
+// and the returned logger to be used for all entries within it.
+func Logger(ctx context.Context) *zap.Logger {
+	logger, _ := ctx.Value(loggerKey{}).(*zap.Logger)
+	if logger == nil {
+		logger = zap.L()
+	}
+
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return NewCore(ctx, core)
+	}))
+}
+
+type loggerKey struct{}
@@ -0,0 +1,86 @@
+package ctxfzap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pamburus/ctxf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewCore(t *testing.T) {
+	inner, logs := observer.New(zapcore.InfoLevel)
+	ctx := ctxf.New(context.Background(), ctxf.String("user", "alice"), ctxf.Int("n", 1), ctxf.Int("n", 2))
+
+	logger := zap.New(NewCore(ctx, inner))
+	logger.Info("hello", zap.String("extra", "x"))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{
+		"user":  "alice",
+		"n":     int64(2),
+		"extra": "x",
+	}, entries[0].ContextMap())
+}
+
+func TestNewCoreWithoutFields(t *testing.T) {
+	inner, logs := observer.New(zapcore.InfoLevel)
+	core := NewCore(context.Background(), inner)
+	assert.Equal(t, context.Background(), core.Context())
+	assert.Equal(t, zapcore.InfoLevel, core.Level())
+
+	require.NoError(t, core.Write(zapcore.Entry{Message: "hello"}, []zapcore.Field{zap.Int("n", 1)}))
+	require.NoError(t, core.Sync())
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{"n": int64(1)}, entries[0].ContextMap())
+}
+
+func TestCoreWith(t *testing.T) {
+	inner, logs := observer.New(zapcore.InfoLevel)
+	ctx := ctxf.New(context.Background(), ctxf.String("user", "alice"))
+
+	core := NewCore(ctx, inner).With([]zapcore.Field{zap.String("component", "api")})
+	assert.Nil(t, core.Check(zapcore.Entry{Level: zapcore.DebugLevel}, nil))
+	ce := core.Check(zapcore.Entry{Level: zapcore.InfoLevel, Message: "hello"}, nil)
+	require.NotNil(t, ce)
+	ce.Write()
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{"user": "alice", "component": "api"}, entries[0].ContextMap())
+}
+
+func TestLogger(t *testing.T) {
+	inner, logs := observer.New(zapcore.InfoLevel)
+	ctx := WithLogger(context.Background(), zap.New(inner))
+	ctx = ctx.With(ctxf.String("request_id", "r1"))
+
+	logger := Logger(ctx)
+	logger.Info("first")
+	logger.Debug("skipped")
+	logger.Info("second")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, map[string]interface{}{"request_id": "r1"}, entry.ContextMap())
+	}
+}
+
+func TestLoggerGlobal(t *testing.T) {
+	inner, logs := observer.New(zapcore.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(inner))()
+
+	Logger(ctxf.New(context.Background(), ctxf.Bool("ok", true))).Info("hello")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{"ok": true}, entries[0].ContextMap())
+}
@@ -0,0 +1,575 @@
+// Package ctxfzap provides interoperability between ctxf fields and go.uber.org/zap.
+package ctxfzap
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pamburus/ctxf"
+	"github.com/pamburus/ctxf/internal/visit"
+	"github.com/pamburus/valf"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Field converts the ctxf.Field to zap.Field.
+// Each kind of value is mapped to the matching zap field type,
+// arrays and objects are mapped to zapcore.ArrayMarshaler and zapcore.ObjectMarshaler.
+// Note that Field does not apply the redaction policy, use Fields if it is needed.
+func Field(f ctxf.Field) zap.Field {
+	visitor := fieldVisitor{key: f.Key}
+	f.Value.AcceptVisitor(&visitor)
+
+	return visitor.field
+}
+
+// Fields converts fields to zap fields.
+// The redaction policy set by ctxf.SetRedactionPolicy is applied to the fields.
+func Fields(fields []ctxf.Field) []zap.Field {
+	fields = ctxf.Export(fields)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	result := make([]zap.Field, len(fields))
+	for i := range fields {
+		result[i] = Field(fields[i])
+	}
+
+	return result
+}
+
+// FromFields converts zap fields to ctxf fields.
+//
+// Fields of scalar types are converted to fields of the matching kind.
+// A namespace field turns the fields following it into a group,
+// fields added by inline marshalers are expanded in place and skipped fields are dropped.
+// Values of other fields are converted to the form produced by zapcore.MapObjectEncoder.
+func FromFields(fields []zap.Field) []ctxf.Field {
+	var result []ctxf.Field
+	for i, f := range fields {
+		switch f.Type {
+		case zapcore.SkipType:
+			continue
+		case zapcore.NamespaceType:
+			return append(result, ctxf.Group(f.Key, FromFields(fields[i+1:])...))
+		case zapcore.InlineMarshalerType:
+			result = append(result, encoded(f)...)
+		default:
+			result = append(result, fromField(f))
+		}
+	}
+
+	return result
+}
+
+func fromField(f zap.Field) ctxf.Field {
+	switch f.Type {
+	case zapcore.BoolType:
+		return ctxf.Bool(f.Key, f.Integer == 1)
+	case zapcore.Int64Type:
+		return ctxf.Int64(f.Key, f.Integer)
+	case zapcore.Int32Type:
+		return ctxf.Int32(f.Key, int32(f.Integer))
+	case zapcore.Int16Type:
+		return ctxf.Int16(f.Key, int16(f.Integer))
+	case zapcore.Int8Type:
+		return ctxf.Int8(f.Key, int8(f.Integer))
+	case zapcore.Uint64Type, zapcore.UintptrType:
+		return ctxf.Uint64(f.Key, uint64(f.Integer))
+	case zapcore.Uint32Type:
+		return ctxf.Uint32(f.Key, uint32(f.Integer))
+	case zapcore.Uint16Type:
+		return ctxf.Uint16(f.Key, uint16(f.Integer))
+	case zapcore.Uint8Type:
+		return ctxf.Uint8(f.Key, uint8(f.Integer))
+	case zapcore.Float64Type:
+		return ctxf.Float64(f.Key, math.Float64frombits(uint64(f.Integer)))
+	case zapcore.Float32Type:
+		return ctxf.Float32(f.Key, math.Float32frombits(uint32(f.Integer)))
+	case zapcore.DurationType:
+		return ctxf.Duration(f.Key, time.Duration(f.Integer))
+	case zapcore.TimeType:
+		t := time.Unix(0, f.Integer)
+		if location, ok := f.Interface.(*time.Location); ok {
+			t = t.In(location)
+		}
+
+		return ctxf.Time(f.Key, t)
+	case zapcore.TimeFullType:
+		t, _ := f.Interface.(time.Time)
+
+		return ctxf.Time(f.Key, t)
+	case zapcore.StringType:
+		return ctxf.String(f.Key, f.String)
+	case zapcore.ByteStringType:
+		b, _ := f.Interface.([]byte)
+
+		return ctxf.String(f.Key, string(b))
+	case zapcore.BinaryType:
+		b, _ := f.Interface.([]byte)
+
+		return ctxf.Bytes(f.Key, b)
+	case zapcore.ErrorType:
+		err, _ := f.Interface.(error)
+
+		return ctxf.NamedError(f.Key, err)
+	case zapcore.StringerType:
+		s, _ := f.Interface.(fmt.Stringer)
+
+		return ctxf.Stringer(f.Key, s)
+	case zapcore.ReflectType:
+		return ctxf.Any(f.Key, f.Interface)
+	default:
+		fields := encoded(f)
+		if len(fields) == 0 {
+			return ctxf.Any(f.Key, nil)
+		}
+
+		return fields[0]
+	}
+}
+
+// encoded returns fields produced by adding the zap field to zapcore.MapObjectEncoder.
+func encoded(f zap.Field) []ctxf.Field {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+
+	if f.Type != zapcore.InlineMarshalerType {
+		value, ok := enc.Fields[f.Key]
+		if !ok {
+			return nil
+		}
+
+		return []ctxf.Field{ctxf.Any(f.Key, value)}
+	}
+
+	result := make([]ctxf.Field, 0, len(enc.Fields))
+	for key, value := range enc.Fields {
+		result = append(result, ctxf.Any(key, value))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+
+	return result
+}
+
+// arrayMarshaler implements zapcore.ArrayMarshaler for valf.ValueArray.
+type arrayMarshaler struct {
+	value valf.ValueArray
+}
+
+func (m arrayMarshaler) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	visitor := elementVisitor{enc: enc}
+	m.value.AcceptArrayVisitor(visit.ArrayFunc(func(item valf.Value) {
+		item.AcceptVisitor(&visitor)
+	}))
+
+	return visitor.err
+}
+
+// objectMarshaler implements zapcore.ObjectMarshaler for valf.ValueObject.
+type objectMarshaler struct {
+	value valf.ValueObject
+}
+
+func (m objectMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	m.value.AcceptObjectVisitor(visit.ObjectFunc(func(key string, item valf.Value) {
+		Field(ctxf.Field{Key: key, Value: item}).AddTo(enc)
+	}))
+
+	return nil
+}
+
+// sliceMarshaler implements zapcore.ArrayMarshaler for slices held by elements of arrays.
+type sliceMarshaler[T any] struct {
+	values []T
+	append func(zapcore.ArrayEncoder, T)
+}
+
+func (m sliceMarshaler[T]) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, value := range m.values {
+		m.append(enc, value)
+	}
+
+	return nil
+}
+
+type fieldVisitor struct {
+	key   string
+	field zap.Field
+}
+
+func (v *fieldVisitor) VisitNone() {
+	v.field = zap.Reflect(v.key, nil)
+}
+
+func (v *fieldVisitor) VisitAny(value interface{}) {
+	v.field = zap.Any(v.key, value)
+}
+
+func (v *fieldVisitor) VisitBool(value bool) {
+	v.field = zap.Bool(v.key, value)
+}
+
+func (v *fieldVisitor) VisitInt(value int) {
+	v.field = zap.Int(v.key, value)
+}
+
+func (v *fieldVisitor) VisitInt8(value int8) {
+	v.field = zap.Int8(v.key, value)
+}
+
+func (v *fieldVisitor) VisitInt16(value int16) {
+	v.field = zap.Int16(v.key, value)
+}
+
+func (v *fieldVisitor) VisitInt32(value int32) {
+	v.field = zap.Int32(v.key, value)
+}
+
+func (v *fieldVisitor) VisitInt64(value int64) {
+	v.field = zap.Int64(v.key, value)
+}
+
+func (v *fieldVisitor) VisitUint(value uint) {
+	v.field = zap.Uint(v.key, value)
+}
+
+func (v *fieldVisitor) VisitUint8(value uint8) {
+	v.field = zap.Uint8(v.key, value)
+}
+
+func (v *fieldVisitor) VisitUint16(value uint16) {
+	v.field = zap.Uint16(v.key, value)
+}
+
+func (v *fieldVisitor) VisitUint32(value uint32) {
+	v.field = zap.Uint32(v.key, value)
+}
+
+func (v *fieldVisitor) VisitUint64(value uint64) {
+	v.field = zap.Uint64(v.key, value)
+}
+
+func (v *fieldVisitor) VisitFloat32(value float32) {
+	v.field = zap.Float32(v.key, value)
+}
+
+func (v *fieldVisitor) VisitFloat64(value float64) {
+	v.field = zap.Float64(v.key, value)
+}
+
+func (v *fieldVisitor) VisitDuration(value time.Duration) {
+	v.field = zap.Duration(v.key, value)
+}
+
+func (v *fieldVisitor) VisitTime(value time.Time) {
+	v.field = zap.Time(v.key, value)
+}
+
+func (v *fieldVisitor) VisitError(value error) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.field = zap.NamedError(v.key, value)
+}
+
+func (v *fieldVisitor) VisitString(value string) {
+	v.field = zap.String(v.key, value)
+}
+
+func (v *fieldVisitor) VisitStringer(value fmt.Stringer) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.field = zap.Stringer(v.key, value)
+}
+
+func (v *fieldVisitor) VisitFormatter(verb string, value interface{}) {
+	v.field = zap.String(v.key, fmt.Sprintf(verb, value))
+}
+
+func (v *fieldVisitor) VisitBytes(value []byte) {
+	v.field = zap.Binary(v.key, value)
+}
+
+func (v *fieldVisitor) VisitBools(values []bool) {
+	v.field = zap.Bools(v.key, values)
+}
+
+func (v *fieldVisitor) VisitInts(values []int) {
+	v.field = zap.Ints(v.key, values)
+}
+
+func (v *fieldVisitor) VisitInts8(values []int8) {
+	v.field = zap.Int8s(v.key, values)
+}
+
+func (v *fieldVisitor) VisitInts16(values []int16) {
+	v.field = zap.Int16s(v.key, values)
+}
+
+func (v *fieldVisitor) VisitInts32(values []int32) {
+	v.field = zap.Int32s(v.key, values)
+}
+
+func (v *fieldVisitor) VisitInts64(values []int64) {
+	v.field = zap.Int64s(v.key, values)
+}
+
+func (v *fieldVisitor) VisitUints(values []uint) {
+	v.field = zap.Uints(v.key, values)
+}
+
+func (v *fieldVisitor) VisitUints8(values []uint8) {
+	v.field = zap.Uint8s(v.key, values)
+}
+
+func (v *fieldVisitor) VisitUints16(values []uint16) {
+	v.field = zap.Uint16s(v.key, values)
+}
+
+func (v *fieldVisitor) VisitUints32(values []uint32) {
+	v.field = zap.Uint32s(v.key, values)
+}
+
+func (v *fieldVisitor) VisitUints64(values []uint64) {
+	v.field = zap.Uint64s(v.key, values)
+}
+
+func (v *fieldVisitor) VisitFloats32(values []float32) {
+	v.field = zap.Float32s(v.key, values)
+}
+
+func (v *fieldVisitor) VisitFloats64(values []float64) {
+	v.field = zap.Float64s(v.key, values)
+}
+
+func (v *fieldVisitor) VisitDurations(values []time.Duration) {
+	v.field = zap.Durations(v.key, values)
+}
+
+func (v *fieldVisitor) VisitStrings(values []string) {
+	v.field = zap.Strings(v.key, values)
+}
+
+func (v *fieldVisitor) VisitArray(value valf.ValueArray) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.field = zap.Array(v.key, arrayMarshaler{value})
+}
+
+func (v *fieldVisitor) VisitObject(value valf.ValueObject) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.field = zap.Object(v.key, objectMarshaler{value})
+}
+
+type elementVisitor struct {
+	enc zapcore.ArrayEncoder
+	err error
+}
+
+func (v *elementVisitor) check(err error) {
+	if v.err == nil {
+		v.err = err
+	}
+}
+
+func (v *elementVisitor) VisitNone() {
+	v.check(v.enc.AppendReflected(nil))
+}
+
+func (v *elementVisitor) VisitAny(value interface{}) {
+	v.check(v.enc.AppendReflected(value))
+}
+
+func (v *elementVisitor) VisitBool(value bool) {
+	v.enc.AppendBool(value)
+}
+
+func (v *elementVisitor) VisitInt(value int) {
+	v.enc.AppendInt(value)
+}
+
+func (v *elementVisitor) VisitInt8(value int8) {
+	v.enc.AppendInt8(value)
+}
+
+func (v *elementVisitor) VisitInt16(value int16) {
+	v.enc.AppendInt16(value)
+}
+
+func (v *elementVisitor) VisitInt32(value int32) {
+	v.enc.AppendInt32(value)
+}
+
+func (v *elementVisitor) VisitInt64(value int64) {
+	v.enc.AppendInt64(value)
+}
+
+func (v *elementVisitor) VisitUint(value uint) {
+	v.enc.AppendUint(value)
+}
+
+func (v *elementVisitor) VisitUint8(value uint8) {
+	v.enc.AppendUint8(value)
+}
+
+func (v *elementVisitor) VisitUint16(value uint16) {
+	v.enc.AppendUint16(value)
+}
+
+func (v *elementVisitor) VisitUint32(value uint32) {
+	v.enc.AppendUint32(value)
+}
+
+func (v *elementVisitor) VisitUint64(value uint64) {
+	v.enc.AppendUint64(value)
+}
+
+func (v *elementVisitor) VisitFloat32(value float32) {
+	v.enc.AppendFloat32(value)
+}
+
+func (v *elementVisitor) VisitFloat64(value float64) {
+	v.enc.AppendFloat64(value)
+}
+
+func (v *elementVisitor) VisitDuration(value time.Duration) {
+	v.enc.AppendDuration(value)
+}
+
+func (v *elementVisitor) VisitTime(value time.Time) {
+	v.enc.AppendTime(value)
+}
+
+func (v *elementVisitor) VisitError(value error) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.enc.AppendString(value.Error())
+}
+
+func (v *elementVisitor) VisitString(value string) {
+	v.enc.AppendString(value)
+}
+
+func (v *elementVisitor) VisitStringer(value fmt.Stringer) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.enc.AppendString(value.String())
+}
+
+func (v *elementVisitor) VisitFormatter(verb string, value interface{}) {
+	v.enc.AppendString(fmt.Sprintf(verb, value))
+}
+
+func (v *elementVisitor) VisitBytes(value []byte) {
+	v.check(v.enc.AppendReflected(value))
+}
+
+func (v *elementVisitor) VisitBools(values []bool) {
+	v.check(v.enc.AppendArray(sliceMarshaler[bool]{values, zapcore.ArrayEncoder.AppendBool}))
+}
+
+func (v *elementVisitor) VisitInts(values []int) {
+	v.check(v.enc.AppendArray(sliceMarshaler[int]{values, zapcore.ArrayEncoder.AppendInt}))
+}
+
+func (v *elementVisitor) VisitInts8(values []int8) {
+	v.check(v.enc.AppendArray(sliceMarshaler[int8]{values, zapcore.ArrayEncoder.AppendInt8}))
+}
+
+func (v *elementVisitor) VisitInts16(values []int16) {
+	v.check(v.enc.AppendArray(sliceMarshaler[int16]{values, zapcore.ArrayEncoder.AppendInt16}))
+}
+
+func (v *elementVisitor) VisitInts32(values []int32) {
+	v.check(v.enc.AppendArray(sliceMarshaler[int32]{values, zapcore.ArrayEncoder.AppendInt32}))
+}
+
+func (v *elementVisitor) VisitInts64(values []int64) {
+	v.check(v.enc.AppendArray(sliceMarshaler[int64]{values, zapcore.ArrayEncoder.AppendInt64}))
+}
+
+func (v *elementVisitor) VisitUints(values []uint) {
+	v.check(v.enc.AppendArray(sliceMarshaler[uint]{values, zapcore.ArrayEncoder.AppendUint}))
+}
+
+func (v *elementVisitor) VisitUints8(values []uint8) {
+	v.check(v.enc.AppendArray(sliceMarshaler[uint8]{values, zapcore.ArrayEncoder.AppendUint8}))
+}
+
+func (v *elementVisitor) VisitUints16(values []uint16) {
+	v.check(v.enc.AppendArray(sliceMarshaler[uint16]{values, zapcore.ArrayEncoder.AppendUint16}))
+}
+
+func (v *elementVisitor) VisitUints32(values []uint32) {
+	v.check(v.enc.AppendArray(sliceMarshaler[uint32]{values, zapcore.ArrayEncoder.AppendUint32}))
+}
+
+func (v *elementVisitor) VisitUints64(values []uint64) {
+	v.check(v.enc.AppendArray(sliceMarshaler[uint64]{values, zapcore.ArrayEncoder.AppendUint64}))
+}
+
+func (v *elementVisitor) VisitFloats32(values []float32) {
+	v.check(v.enc.AppendArray(sliceMarshaler[float32]{values, zapcore.ArrayEncoder.AppendFloat32}))
+}
+
+func (v *elementVisitor) VisitFloats64(values []float64) {
+	v.check(v.enc.AppendArray(sliceMarshaler[float64]{values, zapcore.ArrayEncoder.AppendFloat64}))
+}
+
+func (v *elementVisitor) VisitDurations(values []time.Duration) {
+	v.check(v.enc.AppendArray(sliceMarshaler[time.Duration]{values, zapcore.ArrayEncoder.AppendDuration}))
+}
+
+func (v *elementVisitor) VisitStrings(values []string) {
+	v.check(v.enc.AppendArray(sliceMarshaler[string]{values, zapcore.ArrayEncoder.AppendString}))
+}
+
+func (v *elementVisitor) VisitArray(value valf.ValueArray) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.check(v.enc.AppendArray(arrayMarshaler{value}))
+}
+
+func (v *elementVisitor) VisitObject(value valf.ValueObject) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.check(v.enc.AppendObject(objectMarshaler{value}))
+}
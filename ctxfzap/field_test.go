@@ -0,0 +1,172 @@
+package ctxfzap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pamburus/ctxf"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testStringer struct{}
+
+func (testStringer) String() string {
+	return "stringer"
+}
+
+type testArray []int
+
+func (a testArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, v := range a {
+		enc.AppendInt(v)
+	}
+
+	return nil
+}
+
+func TestField(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	err := errors.New("boom")
+
+	assert.Equal(t, []zap.Field{
+		zap.Bool("bool", true),
+		zap.Int("int", -1),
+		zap.Int8("int8", -8),
+		zap.Int16("int16", -16),
+		zap.Int32("int32", -32),
+		zap.Int64("int64", -64),
+		zap.Uint("uint", 1),
+		zap.Uint8("uint8", 8),
+		zap.Uint16("uint16", 16),
+		zap.Uint32("uint32", 32),
+		zap.Uint64("uint64", 64),
+		zap.Float32("float32", 0.25),
+		zap.Float64("float64", 0.125),
+		zap.Duration("duration", time.Second),
+		zap.Time("time", ts),
+		zap.String("string", "s"),
+		zap.NamedError("error", err),
+		zap.Stringer("stringer", testStringer{}),
+		zap.String("formatter", "0x2a"),
+		zap.Binary("bytes", []byte{1, 2}),
+		zap.Strings("strings", []string{"a", "b"}),
+		zap.Ints("ints", []int{1, 2}),
+		zap.Durations("durations", []time.Duration{time.Second}),
+		zap.Any("any", map[string]int{"a": 1}),
+		zap.Reflect("none", nil),
+	}, Fields([]ctxf.Field{
+		ctxf.Bool("bool", true),
+		ctxf.Int("int", -1),
+		ctxf.Int8("int8", -8),
+		ctxf.Int16("int16", -16),
+		ctxf.Int32("int32", -32),
+		ctxf.Int64("int64", -64),
+		ctxf.Uint("uint", 1),
+		ctxf.Uint8("uint8", 8),
+		ctxf.Uint16("uint16", 16),
+		ctxf.Uint32("uint32", 32),
+		ctxf.Uint64("uint64", 64),
+		ctxf.Float32("float32", 0.25),
+		ctxf.Float64("float64", 0.125),
+		ctxf.Duration("duration", time.Second),
+		ctxf.Time("time", ts),
+		ctxf.String("string", "s"),
+		ctxf.NamedError("error", err),
+		ctxf.Stringer("stringer", testStringer{}),
+		ctxf.Formatter("formatter", "%#x", 42),
+		ctxf.Bytes("bytes", []byte{1, 2}),
+		ctxf.Strings("strings", []string{"a", "b"}),
+		ctxf.Ints("ints", []int{1, 2}),
+		ctxf.Durations("durations", []time.Duration{time.Second}),
+		ctxf.Any("any", map[string]int{"a": 1}),
+		ctxf.Any("none", nil),
+	}))
+}
+
+func TestFieldGroup(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	Field(ctxf.Group("http",
+		ctxf.String("method", "GET"),
+		ctxf.Ints("codes", []int{200, 204}),
+		ctxf.Group("peer", ctxf.Int("port", 80)),
+	)).AddTo(enc)
+
+	assert.Equal(t, map[string]interface{}{
+		"http": map[string]interface{}{
+			"method": "GET",
+			"codes":  []interface{}{200, 204},
+			"peer":   map[string]interface{}{"port": int64(80)},
+		},
+	}, enc.Fields)
+}
+
+func TestFieldsRedaction(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range Fields([]ctxf.Field{ctxf.Secret("token", "t0k3n")}) {
+		f.AddTo(enc)
+	}
+
+	assert.Equal(t, map[string]interface{}{"token": ctxf.Redacted}, enc.Fields)
+}
+
+func TestFromFields(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	err := errors.New("boom")
+
+	assert.Equal(t, []ctxf.Field{
+		ctxf.Bool("bool", true),
+		ctxf.Int64("int", -1),
+		ctxf.Int8("int8", -8),
+		ctxf.Uint32("uint32", 32),
+		ctxf.Float32("float32", 0.25),
+		ctxf.Float64("float64", 0.125),
+		ctxf.Duration("duration", time.Second),
+		ctxf.Time("time", ts),
+		ctxf.String("string", "s"),
+		ctxf.String("bytestring", "b"),
+		ctxf.Bytes("binary", []byte{1}),
+		ctxf.NamedError("error", err),
+		ctxf.Stringer("stringer", testStringer{}),
+		ctxf.Any("any", []int{1}),
+		ctxf.Any("array", []interface{}{1, 2}),
+		ctxf.Group("ns",
+			ctxf.String("inner", "x"),
+		),
+	}, FromFields([]zap.Field{
+		zap.Bool("bool", true),
+		zap.Int("int", -1),
+		zap.Int8("int8", -8),
+		zap.Uint32("uint32", 32),
+		zap.Float32("float32", 0.25),
+		zap.Float64("float64", 0.125),
+		zap.Duration("duration", time.Second),
+		zap.Time("time", ts),
+		zap.String("string", "s"),
+		zap.Skip(),
+		zap.ByteString("bytestring", []byte("b")),
+		zap.Binary("binary", []byte{1}),
+		zap.NamedError("error", err),
+		zap.Stringer("stringer", testStringer{}),
+		zap.Reflect("any", []int{1}),
+		zap.Array("array", testArray{1, 2}),
+		zap.Namespace("ns"),
+		zap.String("inner", "x"),
+	}))
+}
+
+func TestFromFieldsInline(t *testing.T) {
+	inline := zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("b", "2")
+		enc.AddString("a", "1")
+
+		return nil
+	})
+
+	assert.Equal(t, []ctxf.Field{
+		ctxf.Any("a", "1"),
+		ctxf.Any("b", "2"),
+	}, FromFields([]zap.Field{zap.Inline(inline)}))
+}
@@ -0,0 +1,458 @@
+// Package ctxfzerolog provides interoperability between ctxf fields and github.com/rs/zerolog.
+package ctxfzerolog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pamburus/ctxf"
+	"github.com/pamburus/ctxf/internal/jsonenc"
+	"github.com/pamburus/ctxf/internal/visit"
+	"github.com/pamburus/valf"
+	"github.com/rs/zerolog"
+)
+
+// Fields is a slice of fields which implements zerolog.LogObjectMarshaler.
+//
+// Values are written with the typed methods of zerolog.Event matching their kinds,
+// arrays and objects are written as zerolog arrays and objects.
+// Only values of kind Any are written using reflection. Elements of arrays holding
+// slices or arrays, which zerolog arrays cannot nest, are written as raw JSON.
+type Fields []ctxf.Field
+
+// MarshalZerologObject writes the fields to the event.
+// Fields with duplicate keys are reduced to the last value.
+// The redaction policy set by ctxf.SetRedactionPolicy is applied to the fields.
+func (f Fields) MarshalZerologObject(e *zerolog.Event) {
+	fields := ctxf.Export(ctxf.Unique(f))
+	for i := range fields {
+		visitor := fieldVisitor{e, fields[i].Key}
+		fields[i].Value.AcceptVisitor(&visitor)
+	}
+}
+
+// AddToEvent writes fields to the event, see Fields for details.
+func AddToEvent(e *zerolog.Event, fields []ctxf.Field) *zerolog.Event {
+	return e.EmbedObject(Fields(fields))
+}
+
+// AddToContext writes fields to the logger context, see Fields for details.
+// The fields are encoded once and included in each event of the resulting logger.
+func AddToContext(c zerolog.Context, fields []ctxf.Field) zerolog.Context {
+	return c.EmbedObject(Fields(fields))
+}
+
+// Hook is a zerolog.Hook which adds fields associated with the context
+// of the event, set with zerolog.Event.Ctx, to the event.
+type Hook struct{}
+
+// Run implements zerolog.Hook.
+func (Hook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if fields := ctxf.Fields(e.GetCtx()); len(fields) != 0 {
+		e.EmbedObject(Fields(fields))
+	}
+}
+
+var _ zerolog.Hook = Hook{}
+
+// arrayMarshaler implements zerolog.LogArrayMarshaler for valf.ValueArray.
+type arrayMarshaler struct {
+	value valf.ValueArray
+}
+
+func (m arrayMarshaler) MarshalZerologArray(a *zerolog.Array) {
+	visitor := elementVisitor{a}
+	m.value.AcceptArrayVisitor(visit.ArrayFunc(func(item valf.Value) {
+		item.AcceptVisitor(&visitor)
+	}))
+}
+
+// objectMarshaler implements zerolog.LogObjectMarshaler for valf.ValueObject.
+type objectMarshaler struct {
+	value valf.ValueObject
+}
+
+func (m objectMarshaler) MarshalZerologObject(e *zerolog.Event) {
+	m.value.AcceptObjectVisitor(visit.ObjectFunc(func(key string, item valf.Value) {
+		visitor := fieldVisitor{e, key}
+		item.AcceptVisitor(&visitor)
+	}))
+}
+
+var (
+	jsonEncoder jsonenc.Encoder
+	null        = []byte("null")
+)
+
+type fieldVisitor struct {
+	e   *zerolog.Event
+	key string
+}
+
+func (v *fieldVisitor) VisitNone() {
+	v.e.RawJSON(v.key, null)
+}
+
+func (v *fieldVisitor) VisitAny(value interface{}) {
+	v.e.Interface(v.key, value)
+}
+
+func (v *fieldVisitor) VisitBool(value bool) {
+	v.e.Bool(v.key, value)
+}
+
+func (v *fieldVisitor) VisitInt(value int) {
+	v.e.Int(v.key, value)
+}
+
+func (v *fieldVisitor) VisitInt8(value int8) {
+	v.e.Int8(v.key, value)
+}
+
+func (v *fieldVisitor) VisitInt16(value int16) {
+	v.e.Int16(v.key, value)
+}
+
+func (v *fieldVisitor) VisitInt32(value int32) {
+	v.e.Int32(v.key, value)
+}
+
+func (v *fieldVisitor) VisitInt64(value int64) {
+	v.e.Int64(v.key, value)
+}
+
+func (v *fieldVisitor) VisitUint(value uint) {
+	v.e.Uint(v.key, value)
+}
+
+func (v *fieldVisitor) VisitUint8(value uint8) {
+	v.e.Uint8(v.key, value)
+}
+
+func (v *fieldVisitor) VisitUint16(value uint16) {
+	v.e.Uint16(v.key, value)
+}
+
+func (v *fieldVisitor) VisitUint32(value uint32) {
+	v.e.Uint32(v.key, value)
+}
+
+func (v *fieldVisitor) VisitUint64(value uint64) {
+	v.e.Uint64(v.key, value)
+}
+
+func (v *fieldVisitor) VisitFloat32(value float32) {
+	v.e.Float32(v.key, value)
+}
+
+func (v *fieldVisitor) VisitFloat64(value float64) {
+	v.e.Float64(v.key, value)
+}
+
+func (v *fieldVisitor) VisitDuration(value time.Duration) {
+	v.e.Dur(v.key, value)
+}
+
+func (v *fieldVisitor) VisitTime(value time.Time) {
+	v.e.Time(v.key, value)
+}
+
+func (v *fieldVisitor) VisitError(value error) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.e.AnErr(v.key, value)
+}
+
+func (v *fieldVisitor) VisitString(value string) {
+	v.e.Str(v.key, value)
+}
+
+func (v *fieldVisitor) VisitStringer(value fmt.Stringer) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.e.Str(v.key, value.String())
+}
+
+func (v *fieldVisitor) VisitFormatter(verb string, value interface{}) {
+	v.e.Str(v.key, fmt.Sprintf(verb, value))
+}
+
+func (v *fieldVisitor) VisitBytes(value []byte) {
+	v.e.Bytes(v.key, value)
+}
+
+func (v *fieldVisitor) VisitBools(values []bool) {
+	v.e.Bools(v.key, values)
+}
+
+func (v *fieldVisitor) VisitInts(values []int) {
+	v.e.Ints(v.key, values)
+}
+
+func (v *fieldVisitor) VisitInts8(values []int8) {
+	v.e.Ints8(v.key, values)
+}
+
+func (v *fieldVisitor) VisitInts16(values []int16) {
+	v.e.Ints16(v.key, values)
+}
+
+func (v *fieldVisitor) VisitInts32(values []int32) {
+	v.e.Ints32(v.key, values)
+}
+
+func (v *fieldVisitor) VisitInts64(values []int64) {
+	v.e.Ints64(v.key, values)
+}
+
+func (v *fieldVisitor) VisitUints(values []uint) {
+	v.e.Uints(v.key, values)
+}
+
+func (v *fieldVisitor) VisitUints8(values []uint8) {
+	v.e.Uints8(v.key, values)
+}
+
+func (v *fieldVisitor) VisitUints16(values []uint16) {
+	v.e.Uints16(v.key, values)
+}
+
+func (v *fieldVisitor) VisitUints32(values []uint32) {
+	v.e.Uints32(v.key, values)
+}
+
+func (v *fieldVisitor) VisitUints64(values []uint64) {
+	v.e.Uints64(v.key, values)
+}
+
+func (v *fieldVisitor) VisitFloats32(values []float32) {
+	v.e.Floats32(v.key, values)
+}
+
+func (v *fieldVisitor) VisitFloats64(values []float64) {
+	v.e.Floats64(v.key, values)
+}
+
+func (v *fieldVisitor) VisitDurations(values []time.Duration) {
+	v.e.Durs(v.key, values)
+}
+
+func (v *fieldVisitor) VisitStrings(values []string) {
+	v.e.Strs(v.key, values)
+}
+
+func (v *fieldVisitor) VisitArray(value valf.ValueArray) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.e.Array(v.key, arrayMarshaler{value})
+}
+
+func (v *fieldVisitor) VisitObject(value valf.ValueObject) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.e.Object(v.key, objectMarshaler{value})
+}
+
+type elementVisitor struct {
+	a *zerolog.Array
+}
+
+func (v *elementVisitor) raw(value valf.Value) {
+	v.a.RawJSON(jsonEncoder.AppendValue(nil, value))
+}
+
+func (v *elementVisitor) VisitNone() {
+	v.a.RawJSON(null)
+}
+
+func (v *elementVisitor) VisitAny(value interface{}) {
+	v.a.Interface(value)
+}
+
+func (v *elementVisitor) VisitBool(value bool) {
+	v.a.Bool(value)
+}
+
+func (v *elementVisitor) VisitInt(value int) {
+	v.a.Int(value)
+}
+
+func (v *elementVisitor) VisitInt8(value int8) {
+	v.a.Int8(value)
+}
+
+func (v *elementVisitor) VisitInt16(value int16) {
+	v.a.Int16(value)
+}
+
+func (v *elementVisitor) VisitInt32(value int32) {
+	v.a.Int32(value)
+}
+
+func (v *elementVisitor) VisitInt64(value int64) {
+	v.a.Int64(value)
+}
+
+func (v *elementVisitor) VisitUint(value uint) {
+	v.a.Uint(value)
+}
+
+func (v *elementVisitor) VisitUint8(value uint8) {
+	v.a.Uint8(value)
+}
+
+func (v *elementVisitor) VisitUint16(value uint16) {
+	v.a.Uint16(value)
+}
+
+func (v *elementVisitor) VisitUint32(value uint32) {
+	v.a.Uint32(value)
+}
+
+func (v *elementVisitor) VisitUint64(value uint64) {
+	v.a.Uint64(value)
+}
+
+func (v *elementVisitor) VisitFloat32(value float32) {
+	v.a.Float32(value)
+}
+
+func (v *elementVisitor) VisitFloat64(value float64) {
+	v.a.Float64(value)
+}
+
+func (v *elementVisitor) VisitDuration(value time.Duration) {
+	v.a.Dur(value)
+}
+
+func (v *elementVisitor) VisitTime(value time.Time) {
+	v.a.Time(value)
+}
+
+func (v *elementVisitor) VisitError(value error) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.a.Err(value)
+}
+
+func (v *elementVisitor) VisitString(value string) {
+	v.a.Str(value)
+}
+
+func (v *elementVisitor) VisitStringer(value fmt.Stringer) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.a.Str(value.String())
+}
+
+func (v *elementVisitor) VisitFormatter(verb string, value interface{}) {
+	v.a.Str(fmt.Sprintf(verb, value))
+}
+
+func (v *elementVisitor) VisitBytes(value []byte) {
+	v.a.Bytes(value)
+}
+
+func (v *elementVisitor) VisitBools(values []bool) {
+	v.raw(valf.Bools(values))
+}
+
+func (v *elementVisitor) VisitInts(values []int) {
+	v.raw(valf.Ints(values))
+}
+
+func (v *elementVisitor) VisitInts8(values []int8) {
+	v.raw(valf.Ints8(values))
+}
+
+func (v *elementVisitor) VisitInts16(values []int16) {
+	v.raw(valf.Ints16(values))
+}
+
+func (v *elementVisitor) VisitInts32(values []int32) {
+	v.raw(valf.Ints32(values))
+}
+
+func (v *elementVisitor) VisitInts64(values []int64) {
+	v.raw(valf.Ints64(values))
+}
+
+func (v *elementVisitor) VisitUints(values []uint) {
+	v.raw(valf.Uints(values))
+}
+
+func (v *elementVisitor) VisitUints8(values []uint8) {
+	v.raw(valf.Uints8(values))
+}
+
+func (v *elementVisitor) VisitUints16(values []uint16) {
+	v.raw(valf.Uints16(values))
+}
+
+func (v *elementVisitor) VisitUints32(values []uint32) {
+	v.raw(valf.Uints32(values))
+}
+
+func (v *elementVisitor) VisitUints64(values []uint64) {
+	v.raw(valf.Uints64(values))
+}
+
+func (v *elementVisitor) VisitFloats32(values []float32) {
+	v.raw(valf.Floats32(values))
+}
+
+func (v *elementVisitor) VisitFloats64(values []float64) {
+	v.raw(valf.Floats64(values))
+}
+
+func (v *elementVisitor) VisitDurations(values []time.Duration) {
+	v.raw(valf.Durations(values))
+}
+
+func (v *elementVisitor) VisitStrings(values []string) {
+	v.raw(valf.Strings(values))
+}
+
+func (v *elementVisitor) VisitArray(value valf.ValueArray) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.raw(valf.Array(value))
+}
+
+func (v *elementVisitor) VisitObject(value valf.ValueObject) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.a.Object(objectMarshaler{value})
+}
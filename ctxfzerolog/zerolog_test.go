@@ -0,0 +1,87 @@
+package ctxfzerolog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pamburus/ctxf"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddToEvent(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	AddToEvent(logger.Info(), []ctxf.Field{
+		ctxf.Bool("bool", true),
+		ctxf.Int("int", 1),
+		ctxf.Int("int", 2),
+		ctxf.Uint8("uint8", 8),
+		ctxf.Float64("float64", 0.5),
+		ctxf.Duration("duration", time.Second),
+		ctxf.Time("time", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)),
+		ctxf.String("string", "s"),
+		ctxf.NamedError("error", errors.New("boom")),
+		ctxf.Formatter("formatter", "%#x", 42),
+		ctxf.Secret("token", "t0k3n"),
+		ctxf.Strings("strings", []string{"a", "b"}),
+		ctxf.Any("none", nil),
+		ctxf.Any("any", map[string]int{"a": 1}),
+		ctxf.Group("http",
+			ctxf.String("method", "GET"),
+			ctxf.Ints("codes", []int{200}),
+		),
+	}).Msg("hello")
+
+	assert.JSONEq(t, `{
+		"level": "info",
+		"message": "hello",
+		"bool": true,
+		"int": 2,
+		"uint8": 8,
+		"float64": 0.5,
+		"duration": 1000,
+		"time": "2020-01-02T03:04:05Z",
+		"string": "s",
+		"error": "boom",
+		"formatter": "0x2a",
+		"token": "[REDACTED]",
+		"strings": ["a", "b"],
+		"none": null,
+		"any": {"a": 1},
+		"http": {"method": "GET", "codes": [200]}
+	}`, buf.String())
+}
+
+func TestAddToContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := AddToContext(zerolog.New(&buf).With(), []ctxf.Field{ctxf.String("user", "alice")}).Logger()
+
+	logger.Info().Msg("first")
+	logger.Info().Msg("second")
+
+	assert.Equal(t,
+		`{"level":"info","user":"alice","message":"first"}`+"\n"+
+			`{"level":"info","user":"alice","message":"second"}`+"\n",
+		buf.String(),
+	)
+}
+
+func TestHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf).Hook(Hook{})
+
+	ctx := ctxf.New(context.Background(), ctxf.String("request_id", "r1"))
+	logger.Info().Ctx(ctx).Msg("hello")
+	logger.Info().Msg("no context")
+
+	assert.Equal(t,
+		`{"level":"info","request_id":"r1","message":"hello"}`+"\n"+
+			`{"level":"info","message":"no context"}`+"\n",
+		buf.String(),
+	)
+}
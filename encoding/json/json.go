@@ -0,0 +1,93 @@
+// Package json implements JSON encoding of ctxf fields.
+//
+// Fields are encoded as a JSON object with a member for each field.
+// Encoding of scalar values does not allocate.
+package json
+
+import (
+	"context"
+
+	"github.com/pamburus/ctxf"
+	"github.com/pamburus/ctxf/internal/jsonenc"
+)
+
+// TimeFormat specifies the way time.Time values are encoded.
+type TimeFormat = jsonenc.TimeFormat
+
+// Supported time formats.
+const (
+	// TimeLayout encodes time as a string formatted with Encoder.TimeLayout.
+	TimeLayout = jsonenc.TimeLayout
+	// TimeUnixSeconds encodes time as a floating point number of seconds since Unix epoch.
+	TimeUnixSeconds = jsonenc.TimeUnixSeconds
+	// TimeUnixMillis encodes time as an integer number of milliseconds since Unix epoch.
+	TimeUnixMillis = jsonenc.TimeUnixMillis
+	// TimeUnixNanos encodes time as an integer number of nanoseconds since Unix epoch.
+	TimeUnixNanos = jsonenc.TimeUnixNanos
+)
+
+// DurationFormat specifies the way time.Duration values are encoded.
+type DurationFormat = jsonenc.DurationFormat
+
+// Supported duration formats.
+const (
+	// DurationSeconds encodes duration as a floating point number of seconds.
+	DurationSeconds = jsonenc.DurationSeconds
+	// DurationNanos encodes duration as an integer number of nanoseconds.
+	DurationNanos = jsonenc.DurationNanos
+	// DurationString encodes duration as a string returned by time.Duration.String.
+	DurationString = jsonenc.DurationString
+)
+
+// NonFiniteFormat specifies the way NaN and infinite float values are encoded.
+type NonFiniteFormat = jsonenc.NonFiniteFormat
+
+// Supported formats of non-finite float values.
+const (
+	// NonFiniteString encodes non-finite values as strings "NaN", "+Inf" and "-Inf".
+	NonFiniteString = jsonenc.NonFiniteString
+	// NonFiniteNull encodes non-finite values as null.
+	NonFiniteNull = jsonenc.NonFiniteNull
+)
+
+// Encoder encodes fields to JSON.
+// The zero value is ready to use and encodes time using time.RFC3339Nano layout,
+// duration as a number of seconds and non-finite float values as strings.
+type Encoder struct {
+	TimeFormat     TimeFormat
+	TimeLayout     string
+	DurationFormat DurationFormat
+	NonFinite      NonFiniteFormat
+}
+
+// AppendFields appends JSON object containing the given fields to the buf.
+// Fields with duplicate keys are reduced to the last value.
+// The redaction policy set by ctxf.SetRedactionPolicy is applied to the fields.
+func (e *Encoder) AppendFields(buf []byte, fields []ctxf.Field) []byte {
+	enc := (*jsonenc.Encoder)(e)
+	fields = ctxf.Export(ctxf.Unique(fields))
+
+	buf = append(buf, '{')
+	for i := range fields {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = enc.AppendKey(buf, fields[i].Key)
+		buf = enc.AppendValue(buf, fields[i].Value)
+	}
+
+	return append(buf, '}')
+}
+
+// AppendContext appends JSON object containing fields associated with the ctx to the buf.
+func (e *Encoder) AppendContext(buf []byte, ctx context.Context) []byte {
+	return e.AppendFields(buf, ctxf.Fields(ctx))
+}
+
+// AppendJSON appends JSON object containing the given fields to the buf
+// using the default Encoder.
+func AppendJSON(buf []byte, fields []ctxf.Field) []byte {
+	return defaultEncoder.AppendFields(buf, fields)
+}
+
+var defaultEncoder Encoder
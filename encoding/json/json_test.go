@@ -0,0 +1,196 @@
+package json
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pamburus/ctxf"
+	"github.com/pamburus/valf"
+	"github.com/stretchr/testify/assert"
+)
+
+type testObject []ctxf.Field
+
+func (o testObject) AcceptObjectVisitor(v valf.ObjectVisitor) {
+	for _, f := range o {
+		v.VisitField(f.Key, f.Value)
+	}
+}
+
+type testArray []valf.Value
+
+func (a testArray) AcceptArrayVisitor(v valf.ArrayVisitor) {
+	for _, item := range a {
+		v.VisitElement(item)
+	}
+}
+
+type testStringer struct{}
+
+func (testStringer) String() string {
+	return "stringer"
+}
+
+func TestAppendJSON(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	tcs := []struct {
+		Name     string
+		Field    ctxf.Field
+		Expected string
+	}{
+		{"None", ctxf.Field{Key: "k"}, `null`},
+		{"Any", ctxf.Any("k", struct{ A int }{1}), `{"A":1}`},
+		{"Bool", ctxf.Bool("k", true), `true`},
+		{"Int", ctxf.Int("k", -1), `-1`},
+		{"Int8", ctxf.Int8("k", -8), `-8`},
+		{"Int16", ctxf.Int16("k", -16), `-16`},
+		{"Int32", ctxf.Int32("k", -32), `-32`},
+		{"Int64", ctxf.Int64("k", math.MinInt64), `-9223372036854775808`},
+		{"Uint", ctxf.Uint("k", 1), `1`},
+		{"Uint8", ctxf.Uint8("k", 8), `8`},
+		{"Uint16", ctxf.Uint16("k", 16), `16`},
+		{"Uint32", ctxf.Uint32("k", 32), `32`},
+		{"Uint64", ctxf.Uint64("k", math.MaxUint64), `18446744073709551615`},
+		{"Float32", ctxf.Float32("k", 0.1), `0.1`},
+		{"Float64", ctxf.Float64("k", 0.1), `0.1`},
+		{"Float64Exp", ctxf.Float64("k", 1e-9), `1e-9`},
+		{"Float64Big", ctxf.Float64("k", 1e21), `1e+21`},
+		{"NaN", ctxf.Float64("k", math.NaN()), `"NaN"`},
+		{"PosInf", ctxf.Float64("k", math.Inf(1)), `"+Inf"`},
+		{"NegInf", ctxf.Float32("k", float32(math.Inf(-1))), `"-Inf"`},
+		{"Duration", ctxf.Duration("k", 1500*time.Millisecond), `1.5`},
+		{"Time", ctxf.Time("k", ts), `"2020-01-02T03:04:05.000000006Z"`},
+		{"Error", ctxf.NamedError("k", errors.New("failed")), `"failed"`},
+		{"NilError", ctxf.NamedError("k", nil), `null`},
+		{"String", ctxf.String("k", "a\"b\\c\n\x01\u2028\xff"), `"a\"b\\c\n\u0001\u2028\ufffd"`},
+		{"Stringer", ctxf.Stringer("k", testStringer{}), `"stringer"`},
+		{"NilStringer", ctxf.Stringer("k", nil), `null`},
+		{"Formatter", ctxf.Formatter("k", "%03d", 7), `"007"`},
+		{"Bytes", ctxf.Bytes("k", []byte("hello")), `"aGVsbG8="`},
+		{"Bools", ctxf.Bools("k", []bool{true, false}), `[true,false]`},
+		{"Ints", ctxf.Ints("k", []int{1, 2}), `[1,2]`},
+		{"Ints8", ctxf.Ints8("k", []int8{1, 2}), `[1,2]`},
+		{"Ints16", ctxf.Ints16("k", []int16{1, 2}), `[1,2]`},
+		{"Ints32", ctxf.Ints32("k", []int32{1, 2}), `[1,2]`},
+		{"Ints64", ctxf.Ints64("k", []int64{1, 2}), `[1,2]`},
+		{"Uints", ctxf.Uints("k", []uint{1, 2}), `[1,2]`},
+		{"Uints8", ctxf.Uints8("k", []uint8{1, 2}), `[1,2]`},
+		{"Uints16", ctxf.Uints16("k", []uint16{1, 2}), `[1,2]`},
+		{"Uints32", ctxf.Uints32("k", []uint32{1, 2}), `[1,2]`},
+		{"Uints64", ctxf.Uints64("k", []uint64{1, 2}), `[1,2]`},
+		{"Floats32", ctxf.Floats32("k", []float32{0.5, 1}), `[0.5,1]`},
+		{"Floats64", ctxf.Floats64("k", []float64{0.5, 1}), `[0.5,1]`},
+		{"Durations", ctxf.Durations("k", []time.Duration{time.Second}), `[1]`},
+		{"Strings", ctxf.Strings("k", []string{"a", "b"}), `["a","b"]`},
+		{"EmptyStrings", ctxf.Strings("k", nil), `[]`},
+		{"Array", ctxf.Array("k", testArray{valf.Int(1), valf.String("x")}), `[1,"x"]`},
+		{"NilArray", ctxf.Array("k", nil), `null`},
+		{"Object", ctxf.Object("k", testObject{ctxf.Int("a", 1), ctxf.Object("b", testObject{})}), `{"a":1,"b":{}}`},
+		{"NilObject", ctxf.Object("k", nil), `null`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Name, func(t *testing.T) {
+			actual := string(AppendJSON(nil, []ctxf.Field{tc.Field}))
+			assert.Equal(t, `{"k":`+tc.Expected+`}`, actual)
+			assert.True(t, json.Valid([]byte(actual)), actual)
+		})
+	}
+}
+
+func TestAppendJSONMultipleFields(t *testing.T) {
+	fields := []ctxf.Field{ctxf.Int("a", 1), ctxf.String("b", "x"), ctxf.Int("a", 2)}
+	assert.Equal(t, `prefix{"a":2,"b":"x"}`, string(AppendJSON([]byte("prefix"), fields)))
+	assert.Equal(t, `{}`, string(AppendJSON(nil, nil)))
+}
+
+func TestEncoderOptions(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 500000000, time.UTC)
+	fields := []ctxf.Field{
+		ctxf.Time("t", ts),
+		ctxf.Duration("d", 1500*time.Millisecond),
+		ctxf.Float64("f", math.NaN()),
+	}
+
+	tcs := []struct {
+		Name     string
+		Encoder  Encoder
+		Expected string
+	}{
+		{
+			"Layout",
+			Encoder{TimeLayout: time.Kitchen, DurationFormat: DurationString, NonFinite: NonFiniteNull},
+			`{"t":"3:04AM","d":"1.5s","f":null}`,
+		},
+		{
+			"UnixSeconds",
+			Encoder{TimeFormat: TimeUnixSeconds, DurationFormat: DurationNanos},
+			`{"t":1577934245.5,"d":1500000000,"f":"NaN"}`,
+		},
+		{
+			"UnixMillis",
+			Encoder{TimeFormat: TimeUnixMillis},
+			`{"t":1577934245500,"d":1.5,"f":"NaN"}`,
+		},
+		{
+			"UnixNanos",
+			Encoder{TimeFormat: TimeUnixNanos},
+			`{"t":1577934245500000000,"d":1.5,"f":"NaN"}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, string(tc.Encoder.AppendFields(nil, fields)))
+		})
+	}
+}
+
+func TestAppendContext(t *testing.T) {
+	var e Encoder
+	ctx := ctxf.New(context.Background(), ctxf.String("user", "alice"))
+	assert.Equal(t, `{"user":"alice"}`, string(e.AppendContext(nil, ctx)))
+	assert.Equal(t, `{}`, string(e.AppendContext(nil, context.Background())))
+}
+
+func TestAppendJSONAllocations(t *testing.T) {
+	ts := time.Now()
+	fields := []ctxf.Field{
+		ctxf.Bool("bool", true),
+		ctxf.Int("int", 42),
+		ctxf.Uint64("uint64", 42),
+		ctxf.Float64("float64", 0.42),
+		ctxf.Duration("duration", time.Second),
+		ctxf.Time("time", ts),
+		ctxf.String("string", "value"),
+		ctxf.ConstInts("ints", []int{1, 2, 3}),
+	}
+
+	buf := make([]byte, 0, 1024)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = AppendJSON(buf[:0], fields)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkAppendJSON(b *testing.B) {
+	fields := []ctxf.Field{
+		ctxf.Bool("bool", true),
+		ctxf.Int("int", 42),
+		ctxf.Float64("float64", 0.42),
+		ctxf.String("string", "value"),
+		ctxf.Time("time", time.Now()),
+	}
+	buf := make([]byte, 0, 1024)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i != b.N; i++ {
+		buf = AppendJSON(buf[:0], fields)
+	}
+}
@@ -0,0 +1,453 @@
+// Package logfmt implements encoding of ctxf fields to logfmt and parsing them back.
+//
+// Nested objects and arrays are flattened using dotted keys. String values which
+// would be parsed back as a value of some other type are quoted, so that a line
+// produced by the Encoder is parsed back to fields of the same kind.
+package logfmt
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pamburus/ctxf"
+	"github.com/pamburus/ctxf/internal/jsonenc"
+	"github.com/pamburus/ctxf/internal/visit"
+	"github.com/pamburus/valf"
+)
+
+// SliceFormat specifies the way slice values are encoded.
+// String elements which are empty or contain a comma or a quote are quoted.
+type SliceFormat int
+
+// Supported slice formats.
+const (
+	// SliceComma encodes slices as comma separated elements, e.g. `k=1,2,3`.
+	SliceComma SliceFormat = iota
+	// SliceBrackets encodes slices as comma separated elements in square brackets, e.g. `k=[1,2,3]`.
+	SliceBrackets
+)
+
+// Encoder encodes fields to logfmt.
+// The zero value is ready to use and encodes time using time.RFC3339Nano layout
+// and slices as comma separated elements.
+type Encoder struct {
+	TimeLayout  string
+	SliceFormat SliceFormat
+}
+
+// AppendFields appends the given fields in logfmt format to the buf.
+// Fields with duplicate keys are reduced to the last value.
+// The redaction policy set by ctxf.SetRedactionPolicy is applied to the fields.
+func (e *Encoder) AppendFields(buf []byte, fields []ctxf.Field) []byte {
+	fields = ctxf.Export(ctxf.Unique(fields))
+
+	s := state{e: e, buf: buf, start: len(buf)}
+	for i := range fields {
+		s.appendField(fields[i].Key, fields[i].Value)
+	}
+
+	return s.buf
+}
+
+// AppendContext appends fields associated with the ctx in logfmt format to the buf.
+func (e *Encoder) AppendContext(buf []byte, ctx context.Context) []byte {
+	return e.AppendFields(buf, ctxf.Fields(ctx))
+}
+
+// AppendFields appends the given fields in logfmt format to the buf
+// using the default Encoder.
+func AppendFields(buf []byte, fields []ctxf.Field) []byte {
+	return defaultEncoder.AppendFields(buf, fields)
+}
+
+var defaultEncoder Encoder
+
+type state struct {
+	e     *Encoder
+	buf   []byte
+	start int
+}
+
+func (s *state) appendField(key string, value valf.Value) {
+	switch value.Type() {
+	case valf.TypeArray, valf.TypeObject:
+		value.AcceptVisitor(&nestedVisitor{state: s, key: key})
+	default:
+		s.appendKey(key)
+		value.AcceptVisitor(&valueVisitor{s})
+	}
+}
+
+func (s *state) appendKey(key string) {
+	if len(s.buf) != s.start {
+		s.buf = append(s.buf, ' ')
+	}
+	if key == "" {
+		key = "_"
+	}
+	for i := 0; i < len(key); i++ {
+		b := key[i]
+		if b <= ' ' || b == '=' || b == '"' || b == 0x7f {
+			b = '_'
+		}
+		s.buf = append(s.buf, b)
+	}
+	s.buf = append(s.buf, '=')
+}
+
+// appendString appends s quoting it if needed.
+// If typed is true, s is also quoted if it may be parsed back as a value of other type.
+func (s *state) appendString(value string, typed bool) {
+	if needsQuoting(value) || typed && mayBeTyped(value) {
+		s.buf = jsonenc.AppendString(s.buf, value)
+	} else {
+		s.buf = append(s.buf, value...)
+	}
+}
+
+// quote quotes everything written to the buf after the given position if needed.
+func (s *state) quote(pos int) {
+	if needsQuoting(string(s.buf[pos:])) {
+		raw := string(s.buf[pos:])
+		s.buf = jsonenc.AppendString(s.buf[:pos], raw)
+	}
+}
+
+func (s *state) appendFloat(value float64, bits int) {
+	switch {
+	case math.IsNaN(value):
+		s.buf = append(s.buf, "NaN"...)
+	case math.IsInf(value, 1):
+		s.buf = append(s.buf, "+Inf"...)
+	case math.IsInf(value, -1):
+		s.buf = append(s.buf, "-Inf"...)
+	default:
+		format := byte('f')
+		if abs := math.Abs(value); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+			format = 'e'
+		}
+		pos := len(s.buf)
+		s.buf = strconv.AppendFloat(s.buf, value, format, -1, bits)
+		if !hasAny(s.buf[pos:], ".e") {
+			s.buf = append(s.buf, '.', '0')
+		}
+	}
+}
+
+func (s *state) appendTime(value time.Time) {
+	layout := s.e.TimeLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	pos := len(s.buf)
+	s.buf = value.AppendFormat(s.buf, layout)
+	s.quote(pos)
+}
+
+func (s *state) appendSlice(n int, appendItem func(int)) {
+	if n == 0 && s.e.SliceFormat == SliceComma {
+		s.buf = append(s.buf, '"', '"')
+
+		return
+	}
+
+	pos := len(s.buf)
+	if s.e.SliceFormat == SliceBrackets {
+		s.buf = append(s.buf, '[')
+	}
+	for i := 0; i != n; i++ {
+		if i != 0 {
+			s.buf = append(s.buf, ',')
+		}
+		appendItem(i)
+	}
+	if s.e.SliceFormat == SliceBrackets {
+		s.buf = append(s.buf, ']')
+	}
+	s.quote(pos)
+}
+
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b <= ' ' || b == '=' || b == '"' || b == '\\' || b == 0x7f {
+				return true
+			}
+			i++
+
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 || unicode.IsSpace(r) || r == '\ufeff' {
+			return true
+		}
+		i += size
+	}
+
+	return false
+}
+
+// mayBeTyped reports whether Parse may infer a type other than string for the unquoted value.
+// It does not parse the value, so it reports true for some values parsed as strings.
+func mayBeTyped(value string) bool {
+	switch value {
+	case "true", "false", "NaN", "+Inf", "-Inf":
+		return true
+	}
+	c := value[0]
+
+	return c == '-' || c == '+' || c == '.' || '0' <= c && c <= '9'
+}
+
+// needsElementQuoting reports whether a string slice element must be quoted
+// to be told apart from the separator and from other elements.
+func needsElementQuoting(s string) bool {
+	return s == "" || strings.ContainsAny(s, `,"`)
+}
+
+func hasAny(buf []byte, chars string) bool {
+	for _, b := range buf {
+		for i := 0; i != len(chars); i++ {
+			if b == chars[i] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type nestedVisitor struct {
+	valf.IgnoringVisitor
+	*state
+	key string
+}
+
+func (v *nestedVisitor) VisitArray(value valf.ValueArray) {
+	if value == nil {
+		v.appendKey(v.key)
+
+		return
+	}
+
+	i := 0
+	value.AcceptArrayVisitor(visit.ArrayFunc(func(item valf.Value) {
+		v.appendField(v.key+"."+strconv.Itoa(i), item)
+		i++
+	}))
+}
+
+func (v *nestedVisitor) VisitObject(value valf.ValueObject) {
+	if value == nil {
+		v.appendKey(v.key)
+
+		return
+	}
+
+	value.AcceptObjectVisitor(visit.ObjectFunc(func(key string, item valf.Value) {
+		v.appendField(v.key+"."+key, item)
+	}))
+}
+
+type valueVisitor struct {
+	*state
+}
+
+func (v *valueVisitor) VisitNone() {}
+
+func (v *valueVisitor) VisitAny(value interface{}) {
+	v.appendString(fmt.Sprintf("%+v", value), true)
+}
+
+func (v *valueVisitor) VisitBool(value bool) {
+	v.buf = strconv.AppendBool(v.buf, value)
+}
+
+func (v *valueVisitor) VisitInt(value int) {
+	v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+}
+
+func (v *valueVisitor) VisitInt8(value int8) {
+	v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+}
+
+func (v *valueVisitor) VisitInt16(value int16) {
+	v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+}
+
+func (v *valueVisitor) VisitInt32(value int32) {
+	v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+}
+
+func (v *valueVisitor) VisitInt64(value int64) {
+	v.buf = strconv.AppendInt(v.buf, value, 10)
+}
+
+func (v *valueVisitor) VisitUint(value uint) {
+	v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+}
+
+func (v *valueVisitor) VisitUint8(value uint8) {
+	v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+}
+
+func (v *valueVisitor) VisitUint16(value uint16) {
+	v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+}
+
+func (v *valueVisitor) VisitUint32(value uint32) {
+	v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+}
+
+func (v *valueVisitor) VisitUint64(value uint64) {
+	v.buf = strconv.AppendUint(v.buf, value, 10)
+}
+
+func (v *valueVisitor) VisitFloat32(value float32) {
+	v.appendFloat(float64(value), 32)
+}
+
+func (v *valueVisitor) VisitFloat64(value float64) {
+	v.appendFloat(value, 64)
+}
+
+func (v *valueVisitor) VisitDuration(value time.Duration) {
+	v.buf = append(v.buf, value.String()...)
+}
+
+func (v *valueVisitor) VisitTime(value time.Time) {
+	v.appendTime(value)
+}
+
+func (v *valueVisitor) VisitError(value error) {
+	if value != nil {
+		v.appendString(value.Error(), true)
+	}
+}
+
+func (v *valueVisitor) VisitString(value string) {
+	v.appendString(value, true)
+}
+
+func (v *valueVisitor) VisitStringer(value fmt.Stringer) {
+	if value != nil {
+		v.appendString(value.String(), true)
+	}
+}
+
+func (v *valueVisitor) VisitFormatter(verb string, value interface{}) {
+	v.appendString(fmt.Sprintf(verb, value), true)
+}
+
+func (v *valueVisitor) VisitBytes(value []byte) {
+	v.appendString(base64.StdEncoding.EncodeToString(value), false)
+}
+
+func (v *valueVisitor) VisitBools(values []bool) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendBool(v.buf, values[i])
+	})
+}
+
+func (v *valueVisitor) VisitInts(values []int) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendInt(v.buf, int64(values[i]), 10)
+	})
+}
+
+func (v *valueVisitor) VisitInts8(values []int8) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendInt(v.buf, int64(values[i]), 10)
+	})
+}
+
+func (v *valueVisitor) VisitInts16(values []int16) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendInt(v.buf, int64(values[i]), 10)
+	})
+}
+
+func (v *valueVisitor) VisitInts32(values []int32) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendInt(v.buf, int64(values[i]), 10)
+	})
+}
+
+func (v *valueVisitor) VisitInts64(values []int64) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendInt(v.buf, values[i], 10)
+	})
+}
+
+func (v *valueVisitor) VisitUints(values []uint) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendUint(v.buf, uint64(values[i]), 10)
+	})
+}
+
+func (v *valueVisitor) VisitUints8(values []uint8) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendUint(v.buf, uint64(values[i]), 10)
+	})
+}
+
+func (v *valueVisitor) VisitUints16(values []uint16) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendUint(v.buf, uint64(values[i]), 10)
+	})
+}
+
+func (v *valueVisitor) VisitUints32(values []uint32) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendUint(v.buf, uint64(values[i]), 10)
+	})
+}
+
+func (v *valueVisitor) VisitUints64(values []uint64) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = strconv.AppendUint(v.buf, values[i], 10)
+	})
+}
+
+func (v *valueVisitor) VisitFloats32(values []float32) {
+	v.appendSlice(len(values), func(i int) {
+		v.appendFloat(float64(values[i]), 32)
+	})
+}
+
+func (v *valueVisitor) VisitFloats64(values []float64) {
+	v.appendSlice(len(values), func(i int) {
+		v.appendFloat(values[i], 64)
+	})
+}
+
+func (v *valueVisitor) VisitDurations(values []time.Duration) {
+	v.appendSlice(len(values), func(i int) {
+		v.buf = append(v.buf, values[i].String()...)
+	})
+}
+
+func (v *valueVisitor) VisitStrings(values []string) {
+	v.appendSlice(len(values), func(i int) {
+		if needsElementQuoting(values[i]) {
+			v.buf = jsonenc.AppendString(v.buf, values[i])
+		} else {
+			v.buf = append(v.buf, values[i]...)
+		}
+	})
+}
+
+func (v *valueVisitor) VisitArray(valf.ValueArray) {}
+
+func (v *valueVisitor) VisitObject(valf.ValueObject) {}
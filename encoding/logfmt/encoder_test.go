@@ -0,0 +1,108 @@
+package logfmt
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pamburus/ctxf"
+	"github.com/pamburus/valf"
+	"github.com/stretchr/testify/assert"
+)
+
+type testObject []ctxf.Field
+
+func (o testObject) AcceptObjectVisitor(v valf.ObjectVisitor) {
+	for _, f := range o {
+		v.VisitField(f.Key, f.Value)
+	}
+}
+
+type testArray []valf.Value
+
+func (a testArray) AcceptArrayVisitor(v valf.ArrayVisitor) {
+	for _, item := range a {
+		v.VisitElement(item)
+	}
+}
+
+func TestAppendFields(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	tcs := []struct {
+		Name     string
+		Field    ctxf.Field
+		Expected string
+	}{
+		{"None", ctxf.Field{Key: "k"}, `k=`},
+		{"Bool", ctxf.Bool("k", true), `k=true`},
+		{"Int", ctxf.Int("k", -1), `k=-1`},
+		{"Uint64", ctxf.Uint64("k", math.MaxUint64), `k=18446744073709551615`},
+		{"Float64", ctxf.Float64("k", 0.5), `k=0.5`},
+		{"Float64Integral", ctxf.Float64("k", 2), `k=2.0`},
+		{"Float64Exp", ctxf.Float64("k", 1e-9), `k=1e-09`},
+		{"Float32", ctxf.Float32("k", 0.1), `k=0.1`},
+		{"NaN", ctxf.Float64("k", math.NaN()), `k=NaN`},
+		{"Inf", ctxf.Float64("k", math.Inf(-1)), `k=-Inf`},
+		{"Duration", ctxf.Duration("k", 1500*time.Millisecond), `k=1.5s`},
+		{"Time", ctxf.Time("k", ts), `k=2020-01-02T03:04:05.000000006Z`},
+		{"Error", ctxf.NamedError("k", errors.New("it failed")), `k="it failed"`},
+		{"String", ctxf.String("k", "value"), `k=value`},
+		{"EmptyString", ctxf.String("k", ""), `k=""`},
+		{"QuotedString", ctxf.String("k", "a \"b\"\n"), `k="a \"b\"\n"`},
+		{"EqualsString", ctxf.String("k", "a=b"), `k="a=b"`},
+		{"AmbiguousString", ctxf.String("k", "42"), `k="42"`},
+		{"AmbiguousBoolString", ctxf.String("k", "true"), `k="true"`},
+		{"NumericPrefixString", ctxf.String("k", "1abc"), `k="1abc"`},
+		{"Bytes", ctxf.Bytes("k", []byte("hello")), `k="aGVsbG8="`},
+		{"Formatter", ctxf.Formatter("k", "%03d", 7), `k="007"`},
+		{"Ints", ctxf.Ints("k", []int{1, 2, 3}), `k=1,2,3`},
+		{"EmptyInts", ctxf.Ints("k", nil), `k=""`},
+		{"Strings", ctxf.Strings("k", []string{"a", "b c"}), `k="a,b c"`},
+		{"StringsWithSeparator", ctxf.Strings("k", []string{"a,b", ""}), `k="\"a,b\",\"\""`},
+		{"Durations", ctxf.Durations("k", []time.Duration{time.Second, time.Minute}), `k=1s,1m0s`},
+		{"Object", ctxf.Object("k", testObject{ctxf.Int("a", 1), ctxf.Object("b", testObject{ctxf.String("c", "x")})}), `k.a=1 k.b.c=x`},
+		{"Array", ctxf.Array("k", testArray{valf.Int(1), valf.String("x")}), `k.0=1 k.1=x`},
+		{"NilObject", ctxf.Object("k", nil), `k=`},
+		{"BadKey", ctxf.Int("a b=\"c\"", 1), `a_b__c_=1`},
+		{"EmptyKey", ctxf.Int("", 1), `_=1`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, string(AppendFields(nil, []ctxf.Field{tc.Field})))
+		})
+	}
+}
+
+func TestAppendFieldsMultiple(t *testing.T) {
+	fields := []ctxf.Field{ctxf.Int("a", 1), ctxf.String("b", "x"), ctxf.Int("a", 2)}
+	assert.Equal(t, `msg=hello a=2 b=x`, string(AppendFields([]byte("msg=hello "), fields)))
+	assert.Equal(t, ``, string(AppendFields(nil, nil)))
+}
+
+func TestEncoderOptions(t *testing.T) {
+	e := Encoder{TimeLayout: time.RFC1123, SliceFormat: SliceBrackets}
+	fields := []ctxf.Field{
+		ctxf.Time("t", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)),
+		ctxf.Ints("i", []int{1, 2}),
+		ctxf.Strings("s", nil),
+	}
+	assert.Equal(t, `t="Thu, 02 Jan 2020 03:04:05 UTC" i=[1,2] s=[]`, string(e.AppendFields(nil, fields)))
+}
+
+func TestAppendContext(t *testing.T) {
+	var e Encoder
+	ctx := ctxf.New(context.Background(), ctxf.String("user", "alice"))
+	assert.Equal(t, `user=alice`, string(e.AppendContext(nil, ctx)))
+}
+
+func TestAppendContextNamespace(t *testing.T) {
+	var e Encoder
+	ctx := ctxf.New(context.Background(), ctxf.Group("http", ctxf.String("method", "GET"))).
+		WithNamespace("db").
+		With(ctxf.Int("rows", 2))
+	assert.Equal(t, `http.method=GET db.rows=2`, string(e.AppendContext(nil, ctx)))
+}
@@ -0,0 +1,186 @@
+package logfmt
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pamburus/ctxf"
+)
+
+// SyntaxError describes a malformed logfmt line.
+type SyntaxError struct {
+	Offset int
+	Msg    string
+}
+
+// Error implements error.
+func (e *SyntaxError) Error() string {
+	return "logfmt: " + e.Msg + " at offset " + strconv.Itoa(e.Offset)
+}
+
+// Parse parses a logfmt line to fields.
+//
+// Each value is converted to a field using the best-fitting constructor.
+// Unquoted values are tried as Bool, Int64, Float64, Duration and Time
+// in that order and fall back to String. Quoted values are always strings.
+// A key without a value is converted to a Bool field with true value.
+func Parse(line string) ([]ctxf.Field, error) {
+	var fields []ctxf.Field
+
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return fields, nil
+		}
+
+		start := i
+		for i < len(line) && !isSpace(line[i]) && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		if i == start {
+			return fields, &SyntaxError{i, "missing key"}
+		}
+		key := line[start:i]
+
+		if i == len(line) || isSpace(line[i]) {
+			fields = append(fields, ctxf.Bool(key, true))
+
+			continue
+		}
+		if line[i] == '"' {
+			return fields, &SyntaxError{i, "unexpected quote in key"}
+		}
+		i++
+
+		if i < len(line) && line[i] == '"' {
+			start = i
+			i++
+			for i < len(line) && line[i] != '"' {
+				if line[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(line) {
+				return fields, &SyntaxError{start, "unterminated quoted value"}
+			}
+			i++
+			value, err := unquote(line[start:i])
+			if err != nil {
+				return fields, &SyntaxError{start, "invalid quoted value"}
+			}
+			fields = append(fields, ctxf.String(key, value))
+
+			continue
+		}
+
+		start = i
+		for i < len(line) && !isSpace(line[i]) {
+			if line[i] == '"' {
+				return fields, &SyntaxError{i, "unexpected quote in value"}
+			}
+			i++
+		}
+		fields = append(fields, infer(key, line[start:i]))
+	}
+}
+
+func infer(key, value string) ctxf.Field {
+	if value == "" {
+		return ctxf.String(key, value)
+	}
+
+	switch value {
+	case "true":
+		return ctxf.Bool(key, true)
+	case "false":
+		return ctxf.Bool(key, false)
+	case "NaN":
+		return ctxf.Float64(key, math.NaN())
+	case "+Inf":
+		return ctxf.Float64(key, math.Inf(1))
+	case "-Inf":
+		return ctxf.Float64(key, math.Inf(-1))
+	}
+
+	c := value[0]
+	if c != '-' && c != '+' && c != '.' && (c < '0' || c > '9') {
+		return ctxf.String(key, value)
+	}
+
+	if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ctxf.Int64(key, v)
+	}
+	if strings.ContainsAny(value, ".eE") && !strings.ContainsAny(value, "xXpP_") {
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return ctxf.Float64(key, v)
+		}
+	}
+	if v, err := time.ParseDuration(value); err == nil {
+		return ctxf.Duration(key, v)
+	}
+	if v, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return ctxf.Time(key, v)
+	}
+
+	return ctxf.String(key, value)
+}
+
+// unquote decodes a quoted string produced by the Encoder.
+// It accepts JSON escape sequences.
+func unquote(s string) (string, error) {
+	s = s[1 : len(s)-1]
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", strconv.ErrSyntax
+		}
+		switch s[i] {
+		case '"', '\\', '/':
+			b.WriteByte(s[i])
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", strconv.ErrSyntax
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", strconv.ErrSyntax
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			return "", strconv.ErrSyntax
+		}
+	}
+
+	return b.String(), nil
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
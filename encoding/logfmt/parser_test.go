@@ -0,0 +1,153 @@
+package logfmt
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pamburus/ctxf"
+	"github.com/pamburus/valf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	fields, err := Parse(`  a=1 b=-2.5 c=true d=1m30s e=2020-01-02T03:04:05Z f=x g="y z" h= i "j"="k"`)
+	require.Error(t, err)
+	assert.Equal(t, []ctxf.Field{
+		ctxf.Int64("a", 1),
+		ctxf.Float64("b", -2.5),
+		ctxf.Bool("c", true),
+		ctxf.Duration("d", 90*time.Second),
+		ctxf.Time("e", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)),
+		ctxf.String("f", "x"),
+		ctxf.String("g", "y z"),
+		ctxf.String("h", ""),
+		ctxf.Bool("i", true),
+	}, fields)
+}
+
+func TestParseInference(t *testing.T) {
+	tcs := []struct {
+		Value    string
+		Expected ctxf.Field
+	}{
+		{"0", ctxf.Int64("k", 0)},
+		{"+7", ctxf.Int64("k", 7)},
+		{"1e3", ctxf.Float64("k", 1000)},
+		{".5", ctxf.Float64("k", 0.5)},
+		{"18446744073709551615", ctxf.String("k", "18446744073709551615")},
+		{"0x10", ctxf.String("k", "0x10")},
+		{"1_000", ctxf.String("k", "1_000")},
+		{"-1h", ctxf.Duration("k", -time.Hour)},
+		{"false", ctxf.Bool("k", false)},
+		{"True", ctxf.String("k", "True")},
+		{"2020-01-02", ctxf.String("k", "2020-01-02")},
+		{"/path", ctxf.String("k", "/path")},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Value, func(t *testing.T) {
+			fields, err := Parse("k=" + tc.Value)
+			require.NoError(t, err)
+			assert.Equal(t, []ctxf.Field{tc.Expected}, fields)
+		})
+	}
+}
+
+func TestParseNonFinite(t *testing.T) {
+	fields, err := Parse("a=NaN b=+Inf c=-Inf")
+	require.NoError(t, err)
+	require.Len(t, fields, 3)
+
+	var values []float64
+	for _, f := range fields {
+		values = append(values, ctxfFloat64(t, f))
+	}
+	assert.True(t, math.IsNaN(values[0]))
+	assert.True(t, math.IsInf(values[1], 1))
+	assert.True(t, math.IsInf(values[2], -1))
+}
+
+func TestParseErrors(t *testing.T) {
+	tcs := []struct {
+		Line   string
+		Offset int
+	}{
+		{`=x`, 0},
+		{`a="x`, 2},
+		{`a="\q"`, 2},
+		{`a="\u12"`, 2},
+		{`a"b=1`, 1},
+		{`a=b"c`, 3},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Line, func(t *testing.T) {
+			_, err := Parse(tc.Line)
+			require.Error(t, err)
+			var syntaxErr *SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tc.Offset, syntaxErr.Offset)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	tcs := []struct {
+		Name     string
+		Field    ctxf.Field
+		Expected ctxf.Field
+	}{
+		{"Bool", ctxf.Bool("k", false), ctxf.Bool("k", false)},
+		{"Int", ctxf.Int("k", 42), ctxf.Int64("k", 42)},
+		{"Int8", ctxf.Int8("k", -8), ctxf.Int64("k", -8)},
+		{"Uint32", ctxf.Uint32("k", 32), ctxf.Int64("k", 32)},
+		{"Int64", ctxf.Int64("k", math.MinInt64), ctxf.Int64("k", math.MinInt64)},
+		{"Float64", ctxf.Float64("k", 1.25), ctxf.Float64("k", 1.25)},
+		{"Float64Integral", ctxf.Float64("k", 3), ctxf.Float64("k", 3)},
+		{"Float64Tiny", ctxf.Float64("k", 1e-300), ctxf.Float64("k", 1e-300)},
+		{"Float32", ctxf.Float32("k", 0.5), ctxf.Float64("k", 0.5)},
+		{"Duration", ctxf.Duration("k", 1500*time.Millisecond), ctxf.Duration("k", 1500*time.Millisecond)},
+		{"Time", ctxf.Time("k", ts), ctxf.Time("k", ts)},
+		{"String", ctxf.String("k", "value"), ctxf.String("k", "value")},
+		{"EmptyString", ctxf.String("k", ""), ctxf.String("k", "")},
+		{"NumericString", ctxf.String("k", "42"), ctxf.String("k", "42")},
+		{"DurationString", ctxf.String("k", "1s"), ctxf.String("k", "1s")},
+		{"EscapedString", ctxf.String("k", "a \"b\"\n\\\t\x01 ü"), ctxf.String("k", "a \"b\"\n\\\t\x01 ü")},
+		{"Ints", ctxf.Ints("k", []int{1, 2}), ctxf.String("k", "1,2")},
+		{"Strings", ctxf.Strings("k", []string{"a", "b"}), ctxf.String("k", "a,b")},
+		{"StringsWithSeparator", ctxf.Strings("k", []string{"a,b"}), ctxf.String("k", `"a,b"`)},
+		{"StringsWithQuote", ctxf.Strings("k", []string{`"a`, "b"}), ctxf.String("k", `"\"a",b`)},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Name, func(t *testing.T) {
+			line := AppendFields(nil, []ctxf.Field{ctxf.Int("before", 1), tc.Field, ctxf.Int("after", 2)})
+			fields, err := Parse(string(line))
+			require.NoError(t, err, string(line))
+			assert.Equal(t, []ctxf.Field{ctxf.Int64("before", 1), tc.Expected, ctxf.Int64("after", 2)}, fields, string(line))
+		})
+	}
+}
+
+func ctxfFloat64(t *testing.T, f ctxf.Field) float64 {
+	var v float64Visitor
+	f.Value.AcceptVisitor(&v)
+	require.True(t, v.ok)
+
+	return v.value
+}
+
+type float64Visitor struct {
+	valf.IgnoringVisitor
+	value float64
+	ok    bool
+}
+
+func (v *float64Visitor) VisitFloat64(value float64) {
+	v.value = value
+	v.ok = true
+}
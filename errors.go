@@ -0,0 +1,127 @@
+package ctxf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pamburus/valf"
+)
+
+// ErrorMessageKey is the key of the field holding the error message
+// when an error carrying fields is encoded.
+const ErrorMessageKey = "message"
+
+// FieldError is an error carrying fields associated with the context it happened in.
+type FieldError struct {
+	err    error
+	fields []Field
+}
+
+// WrapError returns an error wrapping the err and carrying fields associated with the ctx
+// followed by the given fields. It returns nil if the err is nil.
+//
+// Fields carried by errors are collected by ErrorFields. When a field holding
+// such an error is encoded, it is rendered as a group with the error message
+// under ErrorMessageKey followed by the collected fields.
+func WrapError(ctx context.Context, err error, fields ...Field) error {
+	if err == nil {
+		return nil
+	}
+
+	ctxFields := Fields(ctx)
+	all := make([]Field, 0, len(ctxFields)+len(fields))
+	all = append(all, ctxFields...)
+	all = append(all, fields...)
+	snapshot(all[len(ctxFields):])
+
+	return &FieldError{err, all}
+}
+
+// Errorf formats an error like fmt.Errorf and wraps it with WrapError.
+func Errorf(ctx context.Context, format string, args ...interface{}) error {
+	return WrapError(ctx, fmt.Errorf(format, args...))
+}
+
+// Error implements error.
+func (e *FieldError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *FieldError) Unwrap() error {
+	return e.err
+}
+
+// Fields returns fields carried by the error itself.
+// Use ErrorFields to collect fields across the whole chain of wrapped errors.
+func (e *FieldError) Fields() []Field {
+	return e.fields
+}
+
+// ErrorFields returns fields carried by the err and all errors it wraps,
+// including errors joined with errors.Join and fields of a *PanicError.
+// Fields of outer errors go first, duplicate keys are reduced to the value
+// carried by the innermost error.
+func ErrorFields(err error) []Field {
+	return Unique(appendErrorFields(nil, err))
+}
+
+func appendErrorFields(fields []Field, err error) []Field {
+	for err != nil {
+		switch e := err.(type) {
+		case *FieldError:
+			fields = append(fields, e.fields...)
+		case *PanicError:
+			fields = append(fields, e.Fields...)
+		}
+
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range multi.Unwrap() {
+				fields = appendErrorFields(fields, err)
+			}
+
+			return fields
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return fields
+}
+
+// expandErrors returns fields with errors carrying fields expanded to groups.
+// It returns the fields as is if there are no such errors.
+func expandErrors(fields []Field) []Field {
+	return transform(fields, func(field Field) (Field, bool, bool) {
+		if field.Value.Type() != valf.TypeError {
+			return field, true, false
+		}
+
+		var visitor errorVisitor
+		field.Value.AcceptVisitor(&visitor)
+		if visitor.err == nil {
+			return field, true, false
+		}
+
+		errFields := ErrorFields(visitor.err)
+		if len(errFields) == 0 {
+			return field, true, false
+		}
+
+		members := make([]Field, 0, len(errFields)+1)
+		members = append(members, String(ErrorMessageKey, visitor.err.Error()))
+		members = append(members, errFields...)
+
+		return Field{field.Key, valf.ConstObject(group(members))}, true, true
+	})
+}
+
+type errorVisitor struct {
+	valf.IgnoringVisitor
+	err error
+}
+
+func (v *errorVisitor) VisitError(value error) {
+	v.err = value
+}
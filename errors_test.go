@@ -0,0 +1,82 @@
+package ctxf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapError(t *testing.T) {
+	ctx := New(context.Background(), String("request_id", "r1"))
+	assert.NoError(t, WrapError(ctx, nil))
+
+	err := WrapError(ctx, io.EOF, Int("offset", 42))
+	assert.EqualError(t, err, "EOF")
+	assert.ErrorIs(t, err, io.EOF)
+
+	var ferr *FieldError
+	require.ErrorAs(t, err, &ferr)
+	assert.Equal(t, []Field{String("request_id", "r1"), Int("offset", 42)}, ferr.Fields())
+}
+
+func TestErrorf(t *testing.T) {
+	ctx := New(context.Background(), String("request_id", "r1"))
+	err := Errorf(ctx, "read %s: %w", "file", io.EOF)
+	assert.EqualError(t, err, "read file: EOF")
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, []Field{String("request_id", "r1")}, ErrorFields(err))
+}
+
+func TestErrorFields(t *testing.T) {
+	ctx := New(context.Background(), String("request_id", "r1"), String("layer", "outer"))
+	inner := WrapError(ctx.With(String("layer", "inner")), io.EOF, Int("offset", 42))
+	middle := fmt.Errorf("middle: %w", inner)
+	outer := WrapError(ctx, middle, String("op", "load"))
+
+	assert.Equal(t, []Field{
+		String("request_id", "r1"),
+		String("layer", "inner"),
+		String("op", "load"),
+		Int("offset", 42),
+	}, ErrorFields(outer))
+
+	joined := errors.Join(WrapError(ctx, io.EOF, Int("a", 1)), WrapError(ctx, io.ErrUnexpectedEOF, Int("b", 2)))
+	assert.ErrorIs(t, joined, io.ErrUnexpectedEOF)
+	assert.Equal(t, []Field{
+		String("request_id", "r1"),
+		String("layer", "outer"),
+		Int("a", 1),
+		Int("b", 2),
+	}, ErrorFields(joined))
+
+	panicked := <-Go(ctx, "worker", func(Context) error { panic("boom") })
+	assert.Equal(t, []Field{
+		String("request_id", "r1"),
+		String("layer", "outer"),
+		String(TaskKey, "worker"),
+	}, ErrorFields(panicked))
+
+	assert.Nil(t, ErrorFields(io.EOF))
+	assert.Nil(t, ErrorFields(nil))
+}
+
+func TestErrorFieldsEncoding(t *testing.T) {
+	ctx := New(context.Background(), String("request_id", "r1"))
+	err := Errorf(ctx, "failed")
+
+	assert.Equal(t, `{"error":{"message":"failed","request_id":"r1"}}`, marshal(t, Error(err)))
+	assert.Equal(t, `{"cause":{"message":"failed","request_id":"r1"}}`, marshal(t, NamedError("cause", err)))
+	assert.Equal(t, `{"error":"EOF"}`, marshal(t, Error(io.EOF)))
+}
+
+func TestErrorFieldsEncodingLazy(t *testing.T) {
+	ctx := New(context.Background(), LazyInt("n", func() int { return 1 }))
+	err := Errorf(ctx, "failed")
+
+	assert.Equal(t, `{"cause":{"message":"failed","n":1}}`, marshal(t, NamedError("cause", err)))
+}
@@ -0,0 +1,136 @@
+package ctxf
+
+import (
+	"github.com/pamburus/valf"
+)
+
+// Group returns a new Field with the given key and the given fields
+// as members of a nested object.
+//
+// Encoders producing nested formats such as JSON and slog render a group as
+// a nested object, flat formats such as logfmt and baggage use dotted keys instead.
+func Group(k string, fields ...Field) Field {
+	members := make([]Field, len(fields))
+	copy(members, fields)
+	snapshot(members)
+
+	return Field{Key: k, Value: valf.ConstObject(group(members))}
+}
+
+// Flatten returns fields with members of groups hoisted to the top level
+// and prefixed with the keys of the groups and a dot, e.g. "http.method".
+// It returns the fields as is if there are no groups.
+func Flatten(fields []Field) []Field {
+	if !hasGroups(fields) {
+		return fields
+	}
+
+	return appendFlat(make([]Field, 0, len(fields)), "", fields)
+}
+
+// WithNamespace returns a new Context which nests all fields added by
+// subsequent calls to With into a group with the given name.
+// Lookup, Without and Replace of the returned Context operate on
+// the fields within the namespace.
+// Fields of a namespace are reduced to unique keys.
+func (c Context) WithNamespace(name string) Context {
+	if name == "" {
+		return c
+	}
+
+	c.fields = newNamespace(c.fields, name)
+
+	return c
+}
+
+// group is a list of fields representing a nested object.
+type group []Field
+
+func (g group) AcceptObjectVisitor(visitor valf.ObjectVisitor) {
+	for i := range g {
+		visitor.VisitField(g[i].Key, g[i].Value)
+	}
+}
+
+func groupMembers(v valf.Value) (group, bool) {
+	if v.Type() != valf.TypeObject {
+		return nil, false
+	}
+
+	var visitor groupVisitor
+	v.AcceptVisitor(&visitor)
+
+	return visitor.members, visitor.ok
+}
+
+func hasGroups(fields []Field) bool {
+	for i := range fields {
+		if _, ok := groupMembers(fields[i].Value); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendFlat(result []Field, prefix string, fields []Field) []Field {
+	for i := range fields {
+		key := prefix + fields[i].Key
+		if members, ok := groupMembers(fields[i].Value); ok {
+			result = appendFlat(result, key+".", members)
+		} else {
+			result = append(result, Field{key, fields[i].Value})
+		}
+	}
+
+	return result
+}
+
+// transform returns fields with fn applied to each of them, including members of groups
+// left intact by fn. The fields are copied only if fn changes any of them.
+// The fn returns the resulting field, false if the field should be dropped
+// and true if the field has been changed.
+func transform(fields []Field, fn func(Field) (Field, bool, bool)) []Field {
+	var result []Field
+	for i := range fields {
+		field, keep, changed := fn(fields[i])
+		if !changed {
+			if members, ok := groupMembers(field.Value); ok {
+				if transformed := transform(members, fn); !same(transformed, members) {
+					field = Field{field.Key, valf.ConstObject(group(transformed))}
+					changed = true
+				}
+			}
+		}
+		if result == nil {
+			if !changed {
+				continue
+			}
+			result = make([]Field, i, len(fields))
+			copy(result, fields[:i])
+		}
+		if keep {
+			result = append(result, field)
+		}
+	}
+
+	if result == nil {
+		return fields
+	}
+
+	return result
+}
+
+func same(a, b []Field) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+type groupVisitor struct {
+	valf.IgnoringVisitor
+	members group
+	ok      bool
+}
+
+func (v *groupVisitor) VisitObject(value valf.ValueObject) {
+	v.members, v.ok = value.(group)
+}
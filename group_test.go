@@ -0,0 +1,92 @@
+package ctxf
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func marshal(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestGroup(t *testing.T) {
+	f := Group("http", String("method", "GET"), Int("status", 200), Group("client", String("ip", "::1")))
+	assert.Equal(t, `{"http":{"method":"GET","status":200,"client":{"ip":"::1"}}}`, marshal(t, f))
+	assert.Equal(t, `{"http":{}}`, marshal(t, Group("http")))
+}
+
+func TestGroupSnapshot(t *testing.T) {
+	data := []byte("abc")
+	fields := []Field{Bytes("data", data)}
+	f := Group("g", fields...)
+	data[0] = 'x'
+	fields[0] = Int("other", 1)
+	assert.Equal(t, `{"g":{"data":"YWJj"}}`, marshal(t, f))
+}
+
+func TestFlatten(t *testing.T) {
+	fields := []Field{
+		String("a", "1"),
+		Group("http", String("method", "GET"), Group("client", String("ip", "::1"))),
+	}
+	assert.Equal(t, []Field{
+		String("a", "1"),
+		String("http.method", "GET"),
+		String("http.client.ip", "::1"),
+	}, Flatten(fields))
+
+	plain := []Field{String("a", "1")}
+	assert.Same(t, &plain[0], &Flatten(plain)[0])
+}
+
+func TestNamespace(t *testing.T) {
+	ctx := New(context.Background(), String("request_id", "r1")).
+		WithNamespace("db").
+		With(String("table", "users"), Int("rows", 1)).
+		With(Int("rows", 2))
+	assert.Equal(t, `{"request_id":"r1","db":{"table":"users","rows":2}}`, marshal(t, ctx))
+
+	field, ok := ctx.Lookup("rows")
+	require.True(t, ok)
+	assert.Equal(t, Int("rows", 2), field)
+	_, ok = ctx.Lookup("request_id")
+	assert.False(t, ok)
+
+	inner := ctx.WithNamespace("tx").With(Bool("readonly", true))
+	assert.Equal(t, `{"request_id":"r1","db":{"table":"users","rows":2,"tx":{"readonly":true}}}`, marshal(t, inner))
+	assert.Equal(t, `{"request_id":"r1","db":{"table":"users","rows":2}}`, marshal(t, ctx))
+
+	without := ctx.Without("table", "request_id")
+	assert.Equal(t, `{"request_id":"r1","db":{"rows":2}}`, marshal(t, without))
+	assert.Equal(t, `{"request_id":"r1","db":{"rows":3}}`, marshal(t, without.Replace(Int("rows", 3))))
+
+	empty := New(context.Background(), String("a", "1")).WithNamespace("empty")
+	assert.Equal(t, []Field{String("a", "1")}, empty.Fields())
+}
+
+func TestNamespaceDecode(t *testing.T) {
+	ctx := New(context.Background()).WithNamespace("app").With(String("name", "x"))
+	ctx2 := DecodeOptional(context.WithValue(ctx, "k", "v")).With(String("version", "1"))
+	assert.Equal(t, `{"app":{"name":"x","version":"1"}}`, marshal(t, ctx2))
+}
+
+func TestExportGroup(t *testing.T) {
+	SetRedactionPolicy(NewRedactionPolicy(RedactionRule{MatchKeys("password"), Drop}))
+	defer SetRedactionPolicy(nil)
+
+	f := Group("user", String("name", "joe"), String("password", "p"), LazyInt("age", func() int { return 42 }))
+	assert.Equal(t, `{"user":{"name":"joe","age":42}}`, marshal(t, f))
+
+	carrier := MapCarrier{}
+	NewBaggagePropagator("user.name", "user.age").Inject(New(context.Background(), f), carrier)
+	assert.Equal(t, "user.name=joe,user.age=42;ctxf-type=int", carrier.Get(BaggageHeader))
+}
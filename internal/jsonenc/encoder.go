@@ -0,0 +1,514 @@
+// Package jsonenc implements JSON encoding of valf values.
+// It is shared by the ctxf package and its encoding/json subpackage.
+package jsonenc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pamburus/ctxf/internal/visit"
+	"github.com/pamburus/valf"
+)
+
+// TimeFormat specifies the way time.Time values are encoded.
+type TimeFormat int
+
+// Supported time formats.
+const (
+	TimeLayout TimeFormat = iota
+	TimeUnixSeconds
+	TimeUnixMillis
+	TimeUnixNanos
+)
+
+// DurationFormat specifies the way time.Duration values are encoded.
+type DurationFormat int
+
+// Supported duration formats.
+const (
+	DurationSeconds DurationFormat = iota
+	DurationNanos
+	DurationString
+)
+
+// NonFiniteFormat specifies the way NaN and infinite float values are encoded.
+type NonFiniteFormat int
+
+// Supported formats of non-finite float values.
+const (
+	NonFiniteString NonFiniteFormat = iota
+	NonFiniteNull
+)
+
+// Encoder encodes valf values to JSON.
+type Encoder struct {
+	TimeFormat     TimeFormat
+	TimeLayout     string
+	DurationFormat DurationFormat
+	NonFinite      NonFiniteFormat
+}
+
+// AppendKey appends the JSON encoded key followed by a colon to the buf.
+func (e *Encoder) AppendKey(buf []byte, key string) []byte {
+	buf = AppendString(buf, key)
+
+	return append(buf, ':')
+}
+
+// AppendValue appends the JSON encoded value to the buf.
+func (e *Encoder) AppendValue(buf []byte, value valf.Value) []byte {
+	v := visitorPool.Get().(*visitor)
+	v.e = e
+	v.buf = buf
+	value.AcceptVisitor(v)
+	buf = v.buf
+	v.e = nil
+	v.buf = nil
+	visitorPool.Put(v)
+
+	return buf
+}
+
+// AppendString appends the JSON encoded string to the buf.
+func AppendString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' {
+				i++
+
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				buf = append(buf, '\\', b)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xf])
+			}
+			i++
+			start = i
+
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[r&0xf])
+			i += size
+			start = i
+
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+
+	return append(buf, '"')
+}
+
+// AppendFloat appends the JSON encoded float value to the buf.
+func (e *Encoder) AppendFloat(buf []byte, f float64, bits int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return e.appendNonFinite(buf, "NaN")
+	case math.IsInf(f, 1):
+		return e.appendNonFinite(buf, "+Inf")
+	case math.IsInf(f, -1):
+		return e.appendNonFinite(buf, "-Inf")
+	}
+
+	// Follow the same rules as encoding/json does.
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		n := len(buf)
+		if n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+
+	return buf
+}
+
+// AppendTime appends the JSON encoded time value to the buf.
+func (e *Encoder) AppendTime(buf []byte, t time.Time) []byte {
+	switch e.TimeFormat {
+	case TimeUnixSeconds:
+		return e.AppendFloat(buf, float64(t.UnixNano())/float64(time.Second), 64)
+	case TimeUnixMillis:
+		return strconv.AppendInt(buf, t.UnixNano()/int64(time.Millisecond), 10)
+	case TimeUnixNanos:
+		return strconv.AppendInt(buf, t.UnixNano(), 10)
+	default:
+		layout := e.TimeLayout
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		buf = append(buf, '"')
+		buf = t.AppendFormat(buf, layout)
+
+		return append(buf, '"')
+	}
+}
+
+// AppendDuration appends the JSON encoded duration value to the buf.
+func (e *Encoder) AppendDuration(buf []byte, d time.Duration) []byte {
+	switch e.DurationFormat {
+	case DurationNanos:
+		return strconv.AppendInt(buf, int64(d), 10)
+	case DurationString:
+		return AppendString(buf, d.String())
+	default:
+		return e.AppendFloat(buf, d.Seconds(), 64)
+	}
+}
+
+func (e *Encoder) appendNonFinite(buf []byte, s string) []byte {
+	if e.NonFinite == NonFiniteNull {
+		return append(buf, "null"...)
+	}
+
+	buf = append(buf, '"')
+	buf = append(buf, s...)
+
+	return append(buf, '"')
+}
+
+const hex = "0123456789abcdef"
+
+var visitorPool = sync.Pool{
+	New: func() interface{} {
+		return &visitor{}
+	},
+}
+
+type visitor struct {
+	e   *Encoder
+	buf []byte
+}
+
+func (v *visitor) VisitNone() {
+	v.buf = append(v.buf, "null"...)
+}
+
+func (v *visitor) VisitAny(value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		v.buf = AppendString(v.buf, fmt.Sprintf("%+v", value))
+
+		return
+	}
+
+	v.buf = append(v.buf, data...)
+}
+
+func (v *visitor) VisitBool(value bool) {
+	v.buf = strconv.AppendBool(v.buf, value)
+}
+
+func (v *visitor) VisitInt(value int) {
+	v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+}
+
+func (v *visitor) VisitInt8(value int8) {
+	v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+}
+
+func (v *visitor) VisitInt16(value int16) {
+	v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+}
+
+func (v *visitor) VisitInt32(value int32) {
+	v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+}
+
+func (v *visitor) VisitInt64(value int64) {
+	v.buf = strconv.AppendInt(v.buf, value, 10)
+}
+
+func (v *visitor) VisitUint(value uint) {
+	v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+}
+
+func (v *visitor) VisitUint8(value uint8) {
+	v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+}
+
+func (v *visitor) VisitUint16(value uint16) {
+	v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+}
+
+func (v *visitor) VisitUint32(value uint32) {
+	v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+}
+
+func (v *visitor) VisitUint64(value uint64) {
+	v.buf = strconv.AppendUint(v.buf, value, 10)
+}
+
+func (v *visitor) VisitFloat32(value float32) {
+	v.buf = v.e.AppendFloat(v.buf, float64(value), 32)
+}
+
+func (v *visitor) VisitFloat64(value float64) {
+	v.buf = v.e.AppendFloat(v.buf, value, 64)
+}
+
+func (v *visitor) VisitDuration(value time.Duration) {
+	v.buf = v.e.AppendDuration(v.buf, value)
+}
+
+func (v *visitor) VisitTime(value time.Time) {
+	v.buf = v.e.AppendTime(v.buf, value)
+}
+
+func (v *visitor) VisitError(value error) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.buf = AppendString(v.buf, value.Error())
+}
+
+func (v *visitor) VisitString(value string) {
+	v.buf = AppendString(v.buf, value)
+}
+
+func (v *visitor) VisitStringer(value fmt.Stringer) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	v.buf = AppendString(v.buf, value.String())
+}
+
+func (v *visitor) VisitFormatter(verb string, value interface{}) {
+	v.buf = AppendString(v.buf, fmt.Sprintf(verb, value))
+}
+
+func (v *visitor) VisitBytes(value []byte) {
+	buf := append(v.buf, '"')
+	n := len(buf)
+	size := base64.StdEncoding.EncodedLen(len(value))
+	if cap(buf)-n < size {
+		grown := make([]byte, n, 2*cap(buf)+size)
+		copy(grown, buf)
+		buf = grown
+	}
+	buf = buf[:n+size]
+	base64.StdEncoding.Encode(buf[n:], value)
+	v.buf = append(buf, '"')
+}
+
+func (v *visitor) VisitBools(values []bool) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendBool(v.buf, value)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitInts(values []int) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitInts8(values []int8) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitInts16(values []int16) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitInts32(values []int32) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendInt(v.buf, int64(value), 10)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitInts64(values []int64) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendInt(v.buf, value, 10)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitUints(values []uint) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitUints8(values []uint8) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitUints16(values []uint16) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitUints32(values []uint32) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendUint(v.buf, uint64(value), 10)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitUints64(values []uint64) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = strconv.AppendUint(v.buf, value, 10)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitFloats32(values []float32) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = v.e.AppendFloat(v.buf, float64(value), 32)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitFloats64(values []float64) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = v.e.AppendFloat(v.buf, value, 64)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitDurations(values []time.Duration) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = v.e.AppendDuration(v.buf, value)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitStrings(values []string) {
+	v.buf = append(v.buf, '[')
+	for i, value := range values {
+		v.buf = appendComma(v.buf, i)
+		v.buf = AppendString(v.buf, value)
+	}
+	v.buf = append(v.buf, ']')
+}
+
+func (v *visitor) VisitArray(value valf.ValueArray) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	buf := append(v.buf, '[')
+	i := 0
+	value.AcceptArrayVisitor(visit.ArrayFunc(func(item valf.Value) {
+		buf = appendComma(buf, i)
+		buf = v.e.AppendValue(buf, item)
+		i++
+	}))
+	v.buf = append(buf, ']')
+}
+
+func (v *visitor) VisitObject(value valf.ValueObject) {
+	if value == nil {
+		v.VisitNone()
+
+		return
+	}
+
+	buf := append(v.buf, '{')
+	i := 0
+	value.AcceptObjectVisitor(visit.ObjectFunc(func(key string, item valf.Value) {
+		buf = appendComma(buf, i)
+		buf = v.e.AppendKey(buf, key)
+		buf = v.e.AppendValue(buf, item)
+		i++
+	}))
+	v.buf = append(buf, '}')
+}
+
+func appendComma(buf []byte, i int) []byte {
+	if i != 0 {
+		buf = append(buf, ',')
+	}
+
+	return buf
+}
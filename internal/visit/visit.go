@@ -0,0 +1,23 @@
+// Package visit provides function adapters for valf array and object visitors.
+// It is shared by the ctxf package and its subpackages.
+package visit
+
+import (
+	"github.com/pamburus/valf"
+)
+
+// ArrayFunc adapts a function to valf.ArrayVisitor.
+type ArrayFunc func(valf.Value)
+
+// VisitElement calls f(value).
+func (f ArrayFunc) VisitElement(value valf.Value) {
+	f(value)
+}
+
+// ObjectFunc adapts a function to valf.ObjectVisitor.
+type ObjectFunc func(string, valf.Value)
+
+// VisitField calls f(key, value).
+func (f ObjectFunc) VisitField(key string, value valf.Value) {
+	f(key, value)
+}
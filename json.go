@@ -0,0 +1,34 @@
+package ctxf
+
+import (
+	"github.com/pamburus/ctxf/internal/jsonenc"
+)
+
+// MarshalJSON implements json.Marshaler.
+// The Field is encoded as a JSON object with a single member.
+// The RedactionPolicy set by SetRedactionPolicy is applied to the Field.
+func (f Field) MarshalJSON() ([]byte, error) {
+	return appendJSON(nil, Export([]Field{f})), nil
+}
+
+// MarshalJSON implements json.Marshaler.
+// The Context is encoded as a JSON object with a member for each of its unique fields.
+// The RedactionPolicy set by SetRedactionPolicy is applied to the fields.
+func (c Context) MarshalJSON() ([]byte, error) {
+	return appendJSON(nil, Export(c.UniqueFields())), nil
+}
+
+func appendJSON(buf []byte, fields []Field) []byte {
+	buf = append(buf, '{')
+	for i := range fields {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = jsonEncoder.AppendKey(buf, fields[i].Key)
+		buf = jsonEncoder.AppendValue(buf, fields[i].Value)
+	}
+
+	return append(buf, '}')
+}
+
+var jsonEncoder jsonenc.Encoder
@@ -0,0 +1,32 @@
+package ctxf
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(String("user", "alice"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"user":"alice"}`, string(data))
+
+	data, err = json.Marshal([]Field{Int("a", 1), Ints("b", []int{2, 3})})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"a":1},{"b":[2,3]}]`, string(data))
+}
+
+func TestContextMarshalJSON(t *testing.T) {
+	ctx := New(context.Background(), Int("a", 1), String("b", "x")).With(Int("a", 2))
+
+	data, err := json.Marshal(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":2,"b":"x"}`, string(data))
+
+	data, err = json.Marshal(DecodeOptional(context.Background()))
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(data))
+}
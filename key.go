@@ -0,0 +1,249 @@
+package ctxf
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pamburus/valf"
+)
+
+// Key is a typed key of a field.
+// It allows to create fields and read their values back without type switches.
+//
+// Values are matched by their exact Go type. A field holding a value
+// of a different type, e.g. int for a Key[int64], is reported as not found by Get
+// and causes MustGet to panic.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a new Key with the given name.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name}
+}
+
+// Name returns name of the key.
+func (k Key[T]) Name() string {
+	return k.name
+}
+
+// Field returns a new Field with the key and the given value.
+func (k Key[T]) Field(v T) Field {
+	return Any(k.name, v)
+}
+
+// Get returns value of the last field with the key associated with the ctx.
+// Values of lazy fields are computed.
+// It returns false if there is no such field or its value is not of type T.
+func (k Key[T]) Get(ctx context.Context) (T, bool) {
+	v, err := k.lookup(ctx)
+
+	return v, err == nil
+}
+
+// MustGet returns value of the last field with the key associated with the ctx.
+// It panics if there is no such field or its value is not of type T.
+func (k Key[T]) MustGet(ctx context.Context) T {
+	v, err := k.lookup(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+func (k Key[T]) lookup(ctx context.Context) (T, error) {
+	var zero T
+
+	c, ok := Decode(ctx)
+	if !ok {
+		return zero, fmt.Errorf("ctxf: field %q not found", k.name)
+	}
+
+	field, ok := c.Lookup(k.name)
+	if !ok {
+		return zero, fmt.Errorf("ctxf: field %q not found", k.name)
+	}
+
+	var visitor keyVisitor[T]
+	field.Resolve().Value.AcceptVisitor(&visitor)
+	if !visitor.ok {
+		return zero, fmt.Errorf("ctxf: field %q holds %s, not %T", k.name, visitor.typ(), zero)
+	}
+
+	return visitor.value, nil
+}
+
+type keyVisitor[T any] struct {
+	value  T
+	ok     bool
+	actual interface{}
+}
+
+func (v *keyVisitor[T]) set(value interface{}) {
+	v.actual = value
+	v.value, v.ok = value.(T)
+}
+
+func (v *keyVisitor[T]) typ() string {
+	if v.actual == nil {
+		return "no value"
+	}
+
+	return fmt.Sprintf("%T", v.actual)
+}
+
+func (v *keyVisitor[T]) VisitNone() {}
+
+func (v *keyVisitor[T]) VisitFormatter(_ string, value interface{}) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitAny(value interface{}) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitArray(value valf.ValueArray) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitObject(value valf.ValueObject) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitBool(value bool) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitInt(value int) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitInt8(value int8) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitInt16(value int16) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitInt32(value int32) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitInt64(value int64) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitUint(value uint) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitUint8(value uint8) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitUint16(value uint16) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitUint32(value uint32) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitUint64(value uint64) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitFloat32(value float32) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitFloat64(value float64) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitDuration(value time.Duration) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitTime(value time.Time) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitError(value error) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitString(value string) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitStringer(value fmt.Stringer) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitBytes(value []byte) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitBools(value []bool) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitInts(value []int) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitInts8(value []int8) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitInts16(value []int16) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitInts32(value []int32) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitInts64(value []int64) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitUints(value []uint) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitUints8(value []uint8) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitUints16(value []uint16) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitUints32(value []uint32) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitUints64(value []uint64) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitFloats32(value []float32) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitFloats64(value []float64) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitDurations(value []time.Duration) {
+	v.set(value)
+}
+
+func (v *keyVisitor[T]) VisitStrings(value []string) {
+	v.set(value)
+}
@@ -0,0 +1,68 @@
+package ctxf
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	userID := NewKey[int64]("user_id")
+	tenant := NewKey[string]("tenant")
+	elapsed := NewKey[time.Duration]("elapsed")
+	tags := NewKey[[]string]("tags")
+	reason := NewKey[error]("reason")
+
+	err := errors.New("failed")
+	ctx := New(context.Background(),
+		userID.Field(42),
+		tenant.Field("acme"),
+		elapsed.Field(time.Second),
+		tags.Field([]string{"a", "b"}),
+		reason.Field(err),
+	)
+	ctx = ctx.With(userID.Field(43))
+
+	assert.Equal(t, "user_id", userID.Name())
+	assert.Equal(t, Int64("user_id", 42), userID.Field(42))
+	assert.Equal(t, int64(43), userID.MustGet(ctx))
+	assert.Equal(t, "acme", tenant.MustGet(ctx))
+	assert.Equal(t, time.Second, elapsed.MustGet(ctx))
+	assert.Equal(t, []string{"a", "b"}, tags.MustGet(ctx))
+	assert.Equal(t, err, reason.MustGet(ctx))
+}
+
+func TestKeyMissing(t *testing.T) {
+	key := NewKey[string]("tenant")
+
+	v, ok := key.Get(context.Background())
+	assert.False(t, ok)
+	assert.Equal(t, "", v)
+
+	v, ok = key.Get(New(context.Background(), String("other", "x")))
+	assert.False(t, ok)
+	assert.Equal(t, "", v)
+
+	assert.PanicsWithError(t, `ctxf: field "tenant" not found`, func() {
+		key.MustGet(context.Background())
+	})
+}
+
+func TestKeyTypeMismatch(t *testing.T) {
+	key := NewKey[int64]("user_id")
+	ctx := New(context.Background(), Int("user_id", 42))
+
+	v, ok := key.Get(ctx)
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), v)
+
+	assert.PanicsWithError(t, `ctxf: field "user_id" holds int, not int64`, func() {
+		key.MustGet(ctx)
+	})
+	assert.PanicsWithError(t, `ctxf: field "user_id" holds no value, not int64`, func() {
+		key.MustGet(New(context.Background(), Any("user_id", nil)))
+	})
+}
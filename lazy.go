@@ -0,0 +1,155 @@
+package ctxf
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pamburus/valf"
+)
+
+// Lazy returns a new Field with the given key and value computed by fn.
+//
+// The fn is called at most once, when the value is needed for the first time,
+// e.g. when the field is encoded or propagated. It is safe to encode the field concurrently.
+// The value returned by fn is snapshotted.
+// See SetLazyMode for the way lazy fields are handled by New and With.
+func Lazy(k string, fn func() valf.Value) Field {
+	return Field{Key: k, Value: valf.ConstStringer(&lazyValue{fn: fn})}
+}
+
+// LazyAny returns a new lazy Field with the given key and value of any type computed by fn.
+func LazyAny(k string, fn func() interface{}) Field {
+	return Lazy(k, func() valf.Value { return valf.Any(fn()) })
+}
+
+// LazyBool returns a new lazy Field with the given key and bool computed by fn.
+func LazyBool(k string, fn func() bool) Field {
+	return Lazy(k, func() valf.Value { return valf.Bool(fn()) })
+}
+
+// LazyInt returns a new lazy Field with the given key and int computed by fn.
+func LazyInt(k string, fn func() int) Field {
+	return Lazy(k, func() valf.Value { return valf.Int(fn()) })
+}
+
+// LazyInt64 returns a new lazy Field with the given key and int64 computed by fn.
+func LazyInt64(k string, fn func() int64) Field {
+	return Lazy(k, func() valf.Value { return valf.Int64(fn()) })
+}
+
+// LazyUint64 returns a new lazy Field with the given key and uint64 computed by fn.
+func LazyUint64(k string, fn func() uint64) Field {
+	return Lazy(k, func() valf.Value { return valf.Uint64(fn()) })
+}
+
+// LazyFloat64 returns a new lazy Field with the given key and float64 computed by fn.
+func LazyFloat64(k string, fn func() float64) Field {
+	return Lazy(k, func() valf.Value { return valf.Float64(fn()) })
+}
+
+// LazyString returns a new lazy Field with the given key and string computed by fn.
+func LazyString(k string, fn func() string) Field {
+	return Lazy(k, func() valf.Value { return valf.String(fn()) })
+}
+
+// LazyDuration returns a new lazy Field with the given key and time.Duration computed by fn.
+func LazyDuration(k string, fn func() time.Duration) Field {
+	return Lazy(k, func() valf.Value { return valf.Duration(fn()) })
+}
+
+// LazyTime returns a new lazy Field with the given key and time.Time computed by fn.
+func LazyTime(k string, fn func() time.Time) Field {
+	return Lazy(k, func() valf.Value { return valf.Time(fn()) })
+}
+
+// IsLazy reports whether the Field was created with Lazy or one of its typed variants.
+func (f Field) IsLazy() bool {
+	_, ok := lazy(f.Value)
+
+	return ok
+}
+
+// Resolve returns the Field with the computed value if it is lazy,
+// otherwise it returns the Field as is.
+func (f Field) Resolve() Field {
+	if v, ok := lazy(f.Value); ok {
+		return Field{f.Key, v.resolve()}
+	}
+
+	return f
+}
+
+// LazyMode defines the way lazy fields are handled when they are added to a Context by New or With.
+type LazyMode int32
+
+// Lazy modes.
+const (
+	// LazyDeferred keeps lazy fields deferred until they are needed.
+	// Note that the function computing the value may be called long after
+	// the field is added and from another goroutine.
+	LazyDeferred LazyMode = iota
+	// LazyEager computes values of lazy fields when they are added.
+	LazyEager
+)
+
+// SetLazyMode sets the way lazy fields are handled by New and With.
+// The default mode is LazyDeferred.
+func SetLazyMode(mode LazyMode) {
+	lazyMode.Store(int32(mode))
+}
+
+var lazyMode atomic.Int32
+
+// resolveLazy returns fields with lazy values computed, including members of groups.
+// It returns the fields as is if none of them is lazy.
+func resolveLazy(fields []Field) []Field {
+	return transform(fields, func(field Field) (Field, bool, bool) {
+		if field.Value.Type() == valf.TypeStringer {
+			if v, ok := lazy(field.Value); ok {
+				return Field{field.Key, v.resolve()}, true, true
+			}
+		}
+
+		return field, true, false
+	})
+}
+
+func lazy(v valf.Value) (*lazyValue, bool) {
+	var visitor lazyVisitor
+	v.AcceptVisitor(&visitor)
+
+	return visitor.value, visitor.value != nil
+}
+
+// lazyValue holds a value computed on demand.
+// It is represented as a Stringer so that it is encoded as text even if it is
+// passed to an encoder not aware of lazy values.
+type lazyValue struct {
+	once  sync.Once
+	fn    func() valf.Value
+	value valf.Value
+}
+
+func (v *lazyValue) resolve() valf.Value {
+	v.once.Do(func() {
+		v.value = v.fn().Snapshot()
+		v.fn = nil
+	})
+
+	return v.value
+}
+
+func (v *lazyValue) String() string {
+	return valueText(v.resolve())
+}
+
+type lazyVisitor struct {
+	valf.IgnoringVisitor
+	value *lazyValue
+}
+
+func (v *lazyVisitor) VisitStringer(value fmt.Stringer) {
+	v.value, _ = value.(*lazyValue)
+}
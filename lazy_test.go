@@ -0,0 +1,95 @@
+package ctxf
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pamburus/valf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazy(t *testing.T) {
+	var calls atomic.Int32
+	f := LazyInt64("n", func() int64 {
+		calls.Add(1)
+
+		return 42
+	})
+	assert.True(t, f.IsLazy())
+	assert.False(t, Int64("n", 42).IsLazy())
+
+	ctx := New(context.Background(), f, String("s", "x"))
+	assert.Equal(t, int32(0), calls.Load())
+
+	var wg sync.WaitGroup
+	for i := 0; i != 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := json.Marshal(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, `{"n":42,"s":"x"}`, string(data))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	assert.Equal(t, Int64("n", 42), f.Resolve())
+	assert.Equal(t, int64(42), NewKey[int64]("n").MustGet(ctx))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestLazyVariants(t *testing.T) {
+	now := time.Now()
+	fields := Export([]Field{
+		LazyAny("any", func() interface{} { return []string{"a"} }),
+		LazyBool("bool", func() bool { return true }),
+		LazyInt("int", func() int { return 1 }),
+		LazyUint64("uint64", func() uint64 { return 2 }),
+		LazyFloat64("float64", func() float64 { return 0.5 }),
+		LazyString("string", func() string { return "s" }),
+		LazyDuration("duration", func() time.Duration { return time.Second }),
+		LazyTime("time", func() time.Time { return now }),
+		Lazy("value", func() valf.Value { return valf.Int8(3) }),
+	})
+	assert.Equal(t, []Field{
+		Strings("any", []string{"a"}).Snapshot(),
+		Bool("bool", true),
+		Int("int", 1),
+		Uint64("uint64", 2),
+		Float64("float64", 0.5),
+		String("string", "s"),
+		Duration("duration", time.Second),
+		Time("time", now),
+		Int8("value", 3),
+	}, fields)
+}
+
+func TestLazyExportKeepsFields(t *testing.T) {
+	fields := []Field{String("a", "1"), Int("b", 2)}
+	assert.Equal(t, fields, Export(fields))
+	assert.Same(t, &fields[0], &Export(fields)[0])
+}
+
+func TestLazyEager(t *testing.T) {
+	SetLazyMode(LazyEager)
+	defer SetLazyMode(LazyDeferred)
+
+	calls := 0
+	ctx := New(context.Background(), LazyString("s", func() string {
+		calls++
+
+		return "x"
+	}))
+	assert.Equal(t, 1, calls)
+
+	field, ok := ctx.Lookup("s")
+	require.True(t, ok)
+	assert.False(t, field.IsLazy())
+	assert.Equal(t, String("s", "x"), field)
+}
@@ -0,0 +1,199 @@
+package ctxf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pamburus/valf"
+)
+
+// MergePolicy defines how conflicting fields are resolved when fields of
+// multiple contexts are merged. Fields conflict if they have the same key
+// but values of different types or with different text representation.
+type MergePolicy int
+
+// Merge policies.
+const (
+	// MergeLastWins takes the value from the last context having the key.
+	MergeLastWins MergePolicy = iota
+	// MergeFirstWins takes the value from the first context having the key.
+	MergeFirstWins
+	// MergeErrorOnConflict takes the value from the last context having the key
+	// and reports each conflicting key as *MergeConflictError.
+	MergeErrorOnConflict
+	// MergeCollect collects distinct conflicting values into an array in the order of contexts.
+	MergeCollect
+)
+
+// MergeConflictError describes a key having conflicting values in merged contexts.
+type MergeConflictError struct {
+	Key string
+}
+
+// Error implements error.
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("ctxf: conflicting values of field %q", e.Key)
+}
+
+// Merge returns a Context with cancellation, values and fields of the base
+// extended with fields of the others using MergeLastWins policy.
+func Merge(base context.Context, others ...context.Context) Context {
+	c, _ := MergeLastWins.Merge(base, others...)
+
+	return c
+}
+
+// WithFieldsFrom returns a new Context with fields of the others merged
+// into fields of c using MergeLastWins policy.
+func (c Context) WithFieldsFrom(others ...context.Context) Context {
+	return Merge(c, others...)
+}
+
+// Merge returns a Context with cancellation, values and fields of the base
+// extended with fields of the others using the policy.
+// Duplicate keys within a single context are not considered conflicts,
+// the last value is used. Fields of namespaces are merged as groups,
+// namespaces of the base stay open in the merged Context.
+// Values of lazy fields are not computed, lazy fields do not conflict with other fields.
+// With MergeErrorOnConflict, conflicts are reported as joined *MergeConflictError
+// along with the merged Context.
+func (p MergePolicy) Merge(base context.Context, others ...context.Context) (Context, error) {
+	c := DecodeOptional(base)
+
+	sources := make([][]Field, 0, len(others)+1)
+	sources = append(sources, c.UniqueFields())
+	for _, other := range others {
+		if fields := Fields(other); len(fields) != 0 {
+			sources = append(sources, Unique(fields))
+		}
+	}
+	if len(sources) == 1 {
+		return c, nil
+	}
+
+	var keys []string
+	values := make(map[string][]Field)
+	for _, fields := range sources {
+		for i := range fields {
+			key := fields[i].Key
+			if _, ok := values[key]; !ok {
+				keys = append(keys, key)
+			}
+			values[key] = append(values[key], fields[i])
+		}
+	}
+
+	var errs []error
+	merged := make([]Field, 0, len(keys))
+	for _, key := range keys {
+		candidates := distinct(values[key])
+		switch {
+		case p == MergeFirstWins:
+			merged = append(merged, values[key][0])
+		case p == MergeCollect && len(candidates) > 1:
+			array := make(valueArray, len(candidates))
+			for i := range candidates {
+				array[i] = candidates[i].Value
+			}
+			merged = append(merged, Field{key, valf.ConstArray(array)})
+		default:
+			if p == MergeErrorOnConflict && len(candidates) > 1 {
+				errs = append(errs, &MergeConflictError{key})
+			}
+			merged = append(merged, values[key][len(values[key])-1])
+		}
+	}
+
+	return Context{c.parent, reopen(c.fields, merged)}, errors.Join(errs...)
+}
+
+// reopen returns a chunk holding the fields with namespaces of the base reopened,
+// so that fields added to the merged Context are nested the same way as in the base.
+// Members of the group having the name of a namespace are moved into the namespace.
+func reopen(base *chunk, fields []Field) *chunk {
+	var names []string
+	for ch := base; ch != nil; ch = ch.prev {
+		if ch.namespace != "" {
+			names = append(names, ch.namespace)
+		}
+	}
+
+	var result *chunk
+	for i := len(names) - 1; i >= 0; i-- {
+		var members group
+		if j := index(fields, names[i]); j >= 0 {
+			var ok bool
+			if members, ok = groupMembers(fields[j].Value); !ok {
+				break
+			}
+			rest := make([]Field, 0, len(fields)-1)
+			rest = append(rest, fields[:j]...)
+			fields = append(rest, fields[j+1:]...)
+		}
+		result = newNamespace(newChunk(result, fields), names[i])
+		fields = members
+	}
+
+	return newChunk(result, fields)
+}
+
+func index(fields []Field, key string) int {
+	for i := range fields {
+		if fields[i].Key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// distinct returns fields with non-conflicting values removed, keeping the first of them.
+// Lazy fields are not computed to be compared, they are considered not conflicting
+// with other fields and are dropped unless all fields are lazy.
+func distinct(fields []Field) []Field {
+	if len(fields) == 1 {
+		return fields
+	}
+
+	type signature struct {
+		t    valf.Type
+		text string
+	}
+
+	var result []Field
+	var seen []signature
+	for i := range fields {
+		v := fields[i].Reveal().Value
+		if _, ok := lazy(v); ok {
+			continue
+		}
+		s := signature{v.Type(), valueText(v)}
+		found := false
+		for j := range seen {
+			if seen[j] == s {
+				found = true
+
+				break
+			}
+		}
+		if !found {
+			seen = append(seen, s)
+			result = append(result, fields[i])
+		}
+	}
+	if len(result) == 0 {
+		return fields[:1:1]
+	}
+
+	return result
+}
+
+// valueArray is a list of values representing an array.
+type valueArray []valf.Value
+
+func (a valueArray) AcceptArrayVisitor(visitor valf.ArrayVisitor) {
+	for i := range a {
+		visitor.VisitElement(a[i])
+	}
+}
@@ -0,0 +1,92 @@
+package ctxf
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMergeContexts() (Context, context.Context, context.Context) {
+	base := New(context.WithValue(context.Background(), "k", "v"), String("request_id", "r1"), String("tenant", "acme"))
+	job := New(context.Background(), String("job_id", "j1"), String("tenant", "other"), String("request_id", "r1"))
+	item := context.WithValue(New(context.Background(), Int("item", 7), String("tenant", "third")), "k", "item")
+
+	return base, job, item
+}
+
+func TestMerge(t *testing.T) {
+	base, job, item := newMergeContexts()
+	base, cancel := base.WithCancel()
+
+	ctx := Merge(base, job, item, context.Background())
+	assert.Equal(t, `{"request_id":"r1","tenant":"third","job_id":"j1","item":7}`, marshal(t, ctx))
+	assert.Equal(t, "v", ctx.Value("k"))
+
+	cancel()
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	assert.Equal(t, `{"request_id":"r1","tenant":"other","job_id":"j1"}`, marshal(t, base.WithFieldsFrom(job)))
+	assert.Equal(t, base.Fields(), Merge(base, context.Background()).Fields())
+}
+
+func TestMergePolicies(t *testing.T) {
+	base, job, item := newMergeContexts()
+
+	ctx, err := MergeFirstWins.Merge(base, job, item)
+	require.NoError(t, err)
+	assert.Equal(t, `{"request_id":"r1","tenant":"acme","job_id":"j1","item":7}`, marshal(t, ctx))
+
+	ctx, err = MergeCollect.Merge(base, job, item)
+	require.NoError(t, err)
+	assert.Equal(t, `{"request_id":"r1","tenant":["acme","other","third"],"job_id":"j1","item":7}`, marshal(t, ctx))
+
+	ctx, err = MergeErrorOnConflict.Merge(base, job, item)
+	assert.EqualError(t, err, `ctxf: conflicting values of field "tenant"`)
+	var cerr *MergeConflictError
+	require.ErrorAs(t, err, &cerr)
+	assert.Equal(t, "tenant", cerr.Key)
+	assert.Equal(t, `{"request_id":"r1","tenant":"third","job_id":"j1","item":7}`, marshal(t, ctx))
+
+	_, err = MergeErrorOnConflict.Merge(base, job)
+	assert.Error(t, err)
+	_, err = MergeErrorOnConflict.Merge(base, New(context.Background(), String("request_id", "r1"), Int("n", 1)))
+	assert.NoError(t, err)
+}
+
+func TestMergeConflictByType(t *testing.T) {
+	a := New(context.Background(), Int("n", 1))
+	b := New(context.Background(), String("n", "1"))
+
+	_, err := MergeErrorOnConflict.Merge(a, b)
+	assert.Error(t, err)
+
+	ctx, err := MergeCollect.Merge(a, b, a)
+	require.NoError(t, err)
+	assert.Equal(t, `{"n":[1,"1"]}`, marshal(t, ctx))
+}
+
+func TestMergeKeepsNamespaces(t *testing.T) {
+	base := New(context.Background(), String("a", "1")).WithNamespace("http").With(String("method", "GET"))
+	other := New(context.Background(), String("b", "2"))
+
+	ctx := Merge(base, other).With(String("status", "200"))
+	assert.Equal(t, `{"a":"1","b":"2","http":{"method":"GET","status":"200"}}`, marshal(t, ctx))
+
+	ctx = Merge(base, New(context.Background(), String("http", "x"))).With(String("status", "200"))
+	assert.Equal(t, `{"a":"1","http":"x","status":"200"}`, marshal(t, ctx))
+}
+
+func TestMergeDoesNotResolveLazy(t *testing.T) {
+	a := New(context.Background(), LazyString("n", func() string { panic("must not be called") }))
+	b := New(context.Background(), String("n", "1"))
+
+	ctx, err := MergeErrorOnConflict.Merge(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, `{"n":"1"}`, marshal(t, ctx))
+
+	ctx, err = MergeCollect.Merge(b, a)
+	require.NoError(t, err)
+	assert.True(t, ctx.Fields()[0].IsLazy())
+}
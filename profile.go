@@ -0,0 +1,64 @@
+package ctxf
+
+import (
+	"context"
+	"runtime/pprof"
+	"sort"
+)
+
+// WithProfileLabels returns a Context with fields associated with the ctx and
+// pprof labels of the ctx extended with fields having the given keys.
+//
+// Values are converted to text the same way for all encoders, sensitive fields are skipped
+// and the RedactionPolicy set by SetRedactionPolicy is applied to the other fields.
+// Members of groups are selected by their dotted keys, e.g. "http.route".
+//
+// Note that like pprof.WithLabels it does not apply the labels to the current goroutine,
+// use DoProfiled or pprof.SetGoroutineLabels for that.
+func WithProfileLabels(ctx context.Context, keys ...string) Context {
+	c := DecodeOptional(ctx)
+	c.parent = pprof.WithLabels(c.parent, profileLabels(c, keys))
+
+	return c
+}
+
+// DoProfiled calls fn with a Context having fields associated with the ctx and
+// pprof labels of the ctx extended with fields having the given keys.
+// The labels are applied to the current goroutine while fn runs, see pprof.Do.
+// See WithProfileLabels for details on the way fields are converted to labels.
+func DoProfiled(ctx context.Context, keys []string, fn func(Context)) {
+	c := DecodeOptional(ctx)
+	pprof.Do(c.parent, profileLabels(c, keys), func(ctx context.Context) {
+		c.parent = ctx
+		fn(c)
+	})
+}
+
+// ProfileLabelFields returns pprof labels of the ctx as string fields sorted by key.
+func ProfileLabelFields(ctx context.Context) []Field {
+	var fields []Field
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		fields = append(fields, String(key, value))
+
+		return true
+	})
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+
+	return fields
+}
+
+func profileLabels(c Context, keys []string) pprof.LabelSet {
+	fields := Flatten(exportLabels(c.UniqueFields()))
+
+	labels := make([]string, 0, 2*len(keys))
+	for i := range fields {
+		if !contains(keys, fields[i].Key) {
+			continue
+		}
+		labels = append(labels, fields[i].Key, valueText(fields[i].Value))
+	}
+
+	return pprof.Labels(labels...)
+}
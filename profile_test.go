@@ -0,0 +1,49 @@
+package ctxf
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithProfileLabels(t *testing.T) {
+	parent := pprof.WithLabels(context.Background(), pprof.Labels("existing", "1"))
+	ctx := New(parent,
+		String("tenant", "acme"),
+		Int64("user_id", 42),
+		Duration("elapsed", time.Second),
+		Group("http", String("route", "/users")),
+		Secret("token", "t"),
+		String("other", "x"),
+	)
+
+	labeled := WithProfileLabels(ctx, "tenant", "user_id", "elapsed", "http.route", "token", "missing")
+	assert.Equal(t, ctx.Fields(), labeled.Fields())
+	assert.Equal(t, []Field{
+		String("elapsed", "1s"),
+		String("existing", "1"),
+		String("http.route", "/users"),
+		String("tenant", "acme"),
+		String("user_id", "42"),
+	}, ProfileLabelFields(labeled))
+	assert.Nil(t, ProfileLabelFields(context.Background()))
+}
+
+func TestDoProfiled(t *testing.T) {
+	ctx := New(context.Background(), String("tenant", "acme"), String("other", "x"))
+
+	called := false
+	DoProfiled(ctx, []string{"tenant"}, func(c Context) {
+		called = true
+		assert.Equal(t, ctx.Fields(), c.Fields())
+		assert.Equal(t, []Field{String("tenant", "acme")}, ProfileLabelFields(c))
+
+		value, ok := pprof.Label(c, "tenant")
+		assert.True(t, ok)
+		assert.Equal(t, "acme", value)
+	})
+	assert.True(t, called)
+}
@@ -0,0 +1,49 @@
+package ctxf
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Propagator transfers fields across process boundaries using a Carrier.
+// Implementations define the wire format of the fields.
+type Propagator interface {
+	// Inject adds fields associated with the ctx to the carrier.
+	Inject(ctx context.Context, carrier Carrier)
+	// Extract returns a Context with fields associated with the ctx
+	// extended with fields read from the carrier.
+	Extract(ctx context.Context, carrier Carrier) Context
+}
+
+// SetPropagator sets the Propagator used by Inject and Extract.
+// The default one is a BaggagePropagator not allowing any fields to leave or enter the process.
+func SetPropagator(p Propagator) {
+	if p == nil {
+		p = BaggagePropagator{}
+	}
+	propagator.Store(&p)
+}
+
+// GetPropagator returns the Propagator used by Inject and Extract.
+func GetPropagator() Propagator {
+	if p := propagator.Load(); p != nil {
+		return *p
+	}
+
+	return BaggagePropagator{}
+}
+
+// Inject adds fields associated with the ctx to the carrier
+// using the Propagator set by SetPropagator.
+func Inject(ctx context.Context, carrier Carrier) {
+	GetPropagator().Inject(ctx, carrier)
+}
+
+// Extract returns a Context with fields associated with the ctx
+// extended with fields read from the carrier
+// using the Propagator set by SetPropagator.
+func Extract(ctx context.Context, carrier Carrier) Context {
+	return GetPropagator().Extract(ctx, carrier)
+}
+
+var propagator atomic.Pointer[Propagator]
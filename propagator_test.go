@@ -0,0 +1,23 @@
+package ctxf
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultPropagator(t *testing.T) {
+	ctx := New(context.Background(), String("tenant", "acme"), Int("n", 1))
+
+	carrier := MapCarrier{}
+	Inject(ctx, carrier)
+	assert.Empty(t, carrier)
+
+	SetPropagator(NewBaggagePropagator("tenant", "n"))
+	defer SetPropagator(nil)
+
+	Inject(ctx, carrier)
+	assert.Equal(t, MapCarrier{BaggageHeader: "tenant=acme,n=1;ctxf-type=int"}, carrier)
+	assert.Equal(t, []Field{String("tenant", "acme"), Int("n", 1)}, Extract(context.Background(), carrier).Fields())
+}
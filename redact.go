@@ -0,0 +1,309 @@
+package ctxf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/pamburus/valf"
+)
+
+// Redacted is the text used in place of sensitive values.
+const Redacted = "[REDACTED]"
+
+// Sensitive returns a new Field with the given key and value marked as sensitive.
+//
+// A sensitive value is never revealed implicitly. Encoders and propagators
+// see it as a string equal to Redacted unless the RedactionPolicy set by
+// SetRedactionPolicy specifies another treatment. Use Reveal to get the raw value in-process.
+func Sensitive(k string, v valf.Value) Field {
+	return Field{Key: k, Value: valf.ConstStringer(&sensitiveValue{v.Snapshot()})}
+}
+
+// Secret returns a new Field with the given key and string value marked as sensitive.
+// See Sensitive for details.
+func Secret(k string, v string) Field {
+	return Sensitive(k, valf.String(v))
+}
+
+// IsSensitive reports whether the Field was created with Sensitive or Secret.
+func (f Field) IsSensitive() bool {
+	_, ok := sensitive(f.Value)
+
+	return ok
+}
+
+// Reveal returns the Field with the raw value if it is sensitive,
+// otherwise it returns the Field as is.
+func (f Field) Reveal() Field {
+	if v, ok := sensitive(f.Value); ok {
+		return Field{f.Key, v.value}
+	}
+
+	return f
+}
+
+// Redactor transforms a field matched by a RedactionRule.
+// The field passed to a Redactor is always revealed.
+// It returns false if the field should be dropped.
+type Redactor func(Field) (Field, bool)
+
+// Mask returns a Redactor which replaces values with the given text.
+func Mask(text string) Redactor {
+	return func(f Field) (Field, bool) {
+		return String(f.Key, text), true
+	}
+}
+
+// Hash returns a Redactor which replaces values with hex-encoded HMAC-SHA256
+// of their text representation using the given key.
+// Hashed values can be correlated without being revealed.
+func Hash(key []byte) Redactor {
+	key = append([]byte(nil), key...)
+
+	return func(f Field) (Field, bool) {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(valueText(f.Value)))
+
+		return String(f.Key, hex.EncodeToString(mac.Sum(nil))), true
+	}
+}
+
+// Truncate returns a Redactor which keeps at most n leading characters
+// of the text representation of values and replaces the rest with "...".
+// If n is not positive, only "..." is kept.
+func Truncate(n int) Redactor {
+	if n < 0 {
+		n = 0
+	}
+
+	return func(f Field) (Field, bool) {
+		text := valueText(f.Value)
+		if utf8.RuneCountInString(text) <= n {
+			return String(f.Key, text), true
+		}
+		i := 0
+		for j := 0; j < n; j++ {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+		}
+
+		return String(f.Key, text[:i]+"..."), true
+	}
+}
+
+// Drop is a Redactor which drops fields.
+func Drop(Field) (Field, bool) {
+	return Field{}, false
+}
+
+// Matcher reports whether a RedactionRule applies to a field.
+// The field passed to a Matcher is always revealed.
+type Matcher func(Field) bool
+
+// MatchKeys returns a Matcher which matches fields with any of the given keys.
+func MatchKeys(keys ...string) Matcher {
+	return func(f Field) bool {
+		return contains(keys, f.Key)
+	}
+}
+
+// MatchGlob returns a Matcher which matches fields with keys matching
+// the given pattern. The pattern syntax is the one of path.Match.
+// It panics if the pattern is malformed.
+func MatchGlob(pattern string) Matcher {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Errorf("ctxf: invalid glob pattern %q: %w", pattern, err))
+	}
+
+	return func(f Field) bool {
+		ok, _ := path.Match(pattern, f.Key)
+
+		return ok
+	}
+}
+
+// MatchRegexp returns a Matcher which matches fields with keys matching re.
+func MatchRegexp(re *regexp.Regexp) Matcher {
+	return func(f Field) bool {
+		return re.MatchString(f.Key)
+	}
+}
+
+// MatchValue returns a Matcher which matches fields with scalar values
+// whose text representation is accepted by the classifier.
+func MatchValue(classifier func(string) bool) Matcher {
+	return func(f Field) bool {
+		text, _, ok := formatText(f.Value)
+
+		return ok && classifier(text)
+	}
+}
+
+// IsEmail is a value classifier which reports whether s looks like an email address.
+func IsEmail(s string) bool {
+	local, domain, ok := strings.Cut(s, "@")
+	if !ok || local == "" || strings.ContainsAny(s, " \t\r\n<>") {
+		return false
+	}
+	dot := strings.LastIndexByte(domain, '.')
+
+	return dot > 0 && dot < len(domain)-1 && !strings.Contains(domain, "@")
+}
+
+// IsCardNumber is a value classifier which reports whether s looks like a payment card number,
+// i.e. it consists of 12 to 19 digits, optionally separated by spaces or dashes, and passes the Luhn check.
+func IsCardNumber(s string) bool {
+	sum := 0
+	digits := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '-':
+			continue
+		case c < '0' || c > '9':
+			return false
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+
+	return digits >= 12 && digits <= 19 && sum%10 == 0
+}
+
+// RedactionRule applies Redact to fields matching Match.
+type RedactionRule struct {
+	Match  Matcher
+	Redact Redactor
+}
+
+// RedactionPolicy defines how fields are redacted when they are encoded or propagated.
+//
+// For each field the first matching rule is applied. Sensitive fields not matched
+// by any rule are redacted by Sensitive, or masked with Redacted if it is nil.
+type RedactionPolicy struct {
+	Rules     []RedactionRule
+	Sensitive Redactor
+}
+
+// NewRedactionPolicy returns a new RedactionPolicy with the given rules.
+func NewRedactionPolicy(rules ...RedactionRule) *RedactionPolicy {
+	return &RedactionPolicy{Rules: rules}
+}
+
+// Redact returns fields with the policy applied.
+// Rules are also applied to members of groups not matched as a whole.
+// It returns the fields as is if none of them is affected.
+func (p *RedactionPolicy) Redact(fields []Field) []Field {
+	return transform(fields, p.redact)
+}
+
+func (p *RedactionPolicy) redact(field Field) (result Field, ok bool, changed bool) {
+	revealed := field.Reveal()
+	for _, rule := range p.Rules {
+		if rule.Match(revealed) {
+			result, ok = rule.Redact(revealed)
+
+			return result, ok, true
+		}
+	}
+
+	if field.IsSensitive() {
+		if p.Sensitive != nil {
+			result, ok = p.Sensitive(revealed)
+
+			return result, ok, true
+		}
+
+		return String(field.Key, Redacted), true, true
+	}
+
+	return field, true, false
+}
+
+// SetRedactionPolicy sets the RedactionPolicy applied by Export.
+// Passing nil resets the policy so that only sensitive fields are masked.
+func SetRedactionPolicy(p *RedactionPolicy) {
+	redactionPolicy.Store(p)
+}
+
+// Export returns fields prepared to leave the process, i.e. with errors carrying fields
+// expanded to groups, values of lazy fields computed, including those carried by errors,
+// and the RedactionPolicy set by SetRedactionPolicy applied.
+// All encoders and propagators of the package call Export.
+func Export(fields []Field) []Field {
+	return export(fields, false)
+}
+
+// exportLabels returns fields prepared to be used as propagated values or labels.
+// It is the same as Export except that sensitive fields are dropped before the
+// RedactionPolicy is applied, so that placeholders are never passed on as real values.
+func exportLabels(fields []Field) []Field {
+	return export(fields, true)
+}
+
+func export(fields []Field, dropSensitive bool) []Field {
+	fields = resolveLazy(expandErrors(fields))
+	if dropSensitive {
+		fields = transform(fields, dropSensitiveField)
+	}
+	if p := redactionPolicy.Load(); p != nil {
+		return p.Redact(fields)
+	}
+
+	return fields
+}
+
+func dropSensitiveField(field Field) (Field, bool, bool) {
+	if field.IsSensitive() {
+		return Field{}, false, true
+	}
+
+	return field, true, false
+}
+
+var redactionPolicy atomic.Pointer[RedactionPolicy]
+
+func sensitive(v valf.Value) (*sensitiveValue, bool) {
+	var visitor sensitiveVisitor
+	v.AcceptVisitor(&visitor)
+
+	return visitor.value, visitor.value != nil
+}
+
+// sensitiveValue holds a sensitive value.
+// It is represented as a Stringer so that it is masked even if it is
+// passed to an encoder not aware of sensitive values.
+type sensitiveValue struct {
+	value valf.Value
+}
+
+func (v *sensitiveValue) String() string {
+	return Redacted
+}
+
+func (v *sensitiveValue) GoString() string {
+	return Redacted
+}
+
+type sensitiveVisitor struct {
+	valf.IgnoringVisitor
+	value *sensitiveValue
+}
+
+func (v *sensitiveVisitor) VisitStringer(value fmt.Stringer) {
+	v.value, _ = value.(*sensitiveValue)
+}
@@ -0,0 +1,151 @@
+package ctxf
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/pamburus/valf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensitive(t *testing.T) {
+	f := Secret("token", "t0k3n")
+	assert.True(t, f.IsSensitive())
+	assert.Equal(t, String("token", "t0k3n"), f.Reveal())
+	data, err := json.Marshal(f)
+	require.NoError(t, err)
+	assert.Equal(t, `{"token":"[REDACTED]"}`, string(data))
+
+	f = Sensitive("card", valf.Int64(4111))
+	assert.True(t, f.IsSensitive())
+	assert.Equal(t, Int64("card", 4111), f.Reveal())
+
+	f = String("user", "joe")
+	assert.False(t, f.IsSensitive())
+	assert.Equal(t, f, f.Reveal())
+}
+
+func TestSensitiveSnapshot(t *testing.T) {
+	data := []byte("secret")
+	ctx := New(context.Background(), Sensitive("data", valf.Bytes(data)))
+	data[0] = 'S'
+
+	field, ok := ctx.Lookup("data")
+	require.True(t, ok)
+	revealed, err := json.Marshal(field.Reveal())
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":"c2VjcmV0"}`, string(revealed))
+}
+
+func TestSensitiveWithoutPolicy(t *testing.T) {
+	ctx := New(context.Background(), String("user", "joe"), Secret("token", "t0k3n"))
+
+	data, err := json.Marshal(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"user":"joe","token":"[REDACTED]"}`, string(data))
+
+	carrier := MapCarrier{}
+	NewBaggagePropagator("user", "token").Inject(ctx, carrier)
+	assert.Equal(t, "user=joe", carrier.Get(BaggageHeader))
+}
+
+func TestRedactionPolicy(t *testing.T) {
+	p := NewRedactionPolicy(
+		RedactionRule{MatchKeys("password"), Drop},
+		RedactionRule{MatchGlob("*_token"), Truncate(3)},
+		RedactionRule{MatchRegexp(regexp.MustCompile(`^ip(v[46])?$`)), Hash([]byte("key"))},
+		RedactionRule{MatchValue(IsEmail), Mask("<email>")},
+		RedactionRule{MatchValue(IsCardNumber), Mask("<card>")},
+	)
+
+	fields := []Field{
+		String("user", "joe"),
+		String("password", "p"),
+		String("access_token", "abcdef"),
+		Secret("refresh_token", "xy"),
+		String("ip", "127.0.0.1"),
+		String("contact", "joe@example.com"),
+		String("payment", "4111 1111 1111 1111"),
+		Int("count", 4),
+		Secret("key", "k"),
+	}
+	assert.Equal(t, []Field{
+		String("user", "joe"),
+		String("access_token", "abc..."),
+		String("refresh_token", "xy"),
+		String("ip", hmacHex("key", "127.0.0.1")),
+		String("contact", "<email>"),
+		String("payment", "<card>"),
+		Int("count", 4),
+		String("key", Redacted),
+	}, p.Redact(fields))
+
+	unaffected := []Field{String("user", "joe"), Int("count", 4)}
+	assert.Equal(t, unaffected, p.Redact(unaffected))
+
+	p.Sensitive = Drop
+	assert.Equal(t, []Field{String("user", "joe")}, p.Redact([]Field{String("user", "joe"), Secret("key", "k")}))
+}
+
+func TestTruncateNonPositive(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		f, ok := Truncate(n)(String("k", "abc"))
+		assert.True(t, ok)
+		assert.Equal(t, String("k", "..."), f, n)
+
+		f, ok = Truncate(n)(String("k", ""))
+		assert.True(t, ok)
+		assert.Equal(t, String("k", ""), f, n)
+	}
+}
+
+func TestRedactionPolicyExport(t *testing.T) {
+	SetRedactionPolicy(NewRedactionPolicy(RedactionRule{MatchKeys("user"), Mask("***")}))
+	defer SetRedactionPolicy(nil)
+
+	ctx := New(context.Background(), String("user", "joe"), Secret("token", "t0k3n"), Int("n", 1))
+
+	data, err := json.Marshal(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"user":"***","token":"[REDACTED]","n":1}`, string(data))
+
+	data, err = json.Marshal(String("user", "joe"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"user":"***"}`, string(data))
+
+	carrier := MapCarrier{}
+	NewBaggagePropagator("user", "token").Inject(ctx, carrier)
+	assert.Equal(t, "user=***", carrier.Get(BaggageHeader))
+	assert.Equal(t, []Field{String("user", "***")}, ProfileLabelFields(WithProfileLabels(ctx, "user", "token")))
+
+	carrier = MapCarrier{}
+	grouped := New(context.Background(), Group("auth", String("name", "joe"), Secret("token", "t0k3n")))
+	NewBaggagePropagator("auth.name", "auth.token").Inject(grouped, carrier)
+	assert.Equal(t, "auth.name=joe", carrier.Get(BaggageHeader))
+}
+
+func TestClassifiers(t *testing.T) {
+	assert.True(t, IsEmail("joe@example.com"))
+	assert.False(t, IsEmail("joe@example"))
+	assert.False(t, IsEmail("@example.com"))
+	assert.False(t, IsEmail("joe example.com"))
+
+	assert.True(t, IsCardNumber("4111111111111111"))
+	assert.True(t, IsCardNumber("4111-1111-1111-1111"))
+	assert.False(t, IsCardNumber("4111111111111112"))
+	assert.False(t, IsCardNumber("12345"))
+	assert.False(t, IsCardNumber("4111a111111111111"))
+}
+
+func hmacHex(key, text string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(text))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
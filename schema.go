@@ -0,0 +1,364 @@
+package ctxf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pamburus/valf"
+)
+
+// Cardinality is the expected number of distinct values of a field.
+type Cardinality int
+
+// Cardinality values.
+const (
+	CardinalityUnspecified Cardinality = iota
+	CardinalityLow
+	CardinalityHigh
+)
+
+// String returns name of the cardinality.
+func (c Cardinality) String() string {
+	switch c {
+	case CardinalityLow:
+		return "low"
+	case CardinalityHigh:
+		return "high"
+	default:
+		return "unspecified"
+	}
+}
+
+// KeySchema declares a field key.
+type KeySchema struct {
+	Key         string
+	Type        valf.Type
+	Description string
+	Sensitive   bool
+	Cardinality Cardinality
+}
+
+// SchemaError describes a field violating a Schema.
+type SchemaError struct {
+	Key string
+	Msg string
+}
+
+// Error implements error.
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("ctxf: field %q: %s", e.Key, e.Msg)
+}
+
+// Schema is a registry of declared field keys.
+// It is safe for concurrent use.
+type Schema struct {
+	// Strict makes fields with undeclared keys violate the schema.
+	Strict bool
+
+	mu   sync.RWMutex
+	keys map[string]KeySchema
+}
+
+// NewSchema returns a new Schema with the given keys declared.
+// It panics if a key is declared twice.
+func NewSchema(keys ...KeySchema) *Schema {
+	s := &Schema{}
+	if err := s.Register(keys...); err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// Register declares the given keys.
+// It returns an error if any of the keys is already declared, in that case no keys are declared.
+func (s *Schema) Register(keys ...KeySchema) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, k := range keys {
+		_, ok := s.keys[k.Key]
+		for j := 0; !ok && j < i; j++ {
+			ok = keys[j].Key == k.Key
+		}
+		if ok {
+			return &SchemaError{k.Key, "already declared"}
+		}
+	}
+
+	if s.keys == nil {
+		s.keys = make(map[string]KeySchema, len(keys))
+	}
+	for _, k := range keys {
+		s.keys[k.Key] = k
+	}
+
+	return nil
+}
+
+// Lookup returns declaration of the given key.
+func (s *Schema) Lookup(key string) (KeySchema, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	k, ok := s.keys[key]
+
+	return k, ok
+}
+
+// Validate checks the given fields against the schema and returns
+// all violations joined with errors.Join, or nil if there are none.
+//
+// A field violates the schema if its value type differs from the declared one,
+// if its key is declared sensitive but the field is not created with Sensitive,
+// or, in strict mode, if its key is not declared.
+// Values of lazy fields are not checked to avoid computing them.
+// Fields with keys reserved by ReserveKeys are not checked.
+func (s *Schema) Validate(fields ...Field) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var errs []error
+	for i := range fields {
+		if err := s.validate(fields[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Schema) validate(field Field) error {
+	if IsReservedKey(field.Key) {
+		return nil
+	}
+
+	k, ok := s.keys[field.Key]
+	if !ok {
+		if s.Strict {
+			return &SchemaError{field.Key, "undeclared key"}
+		}
+
+		return nil
+	}
+
+	if k.Sensitive && !field.IsSensitive() {
+		return &SchemaError{field.Key, "declared sensitive but created without Sensitive"}
+	}
+	if field.IsLazy() {
+		return nil
+	}
+
+	actual := field.Reveal().Value.Type()
+	if actual != k.Type {
+		return &SchemaError{field.Key, fmt.Sprintf("expected %s, got %s", typeName(k.Type), typeName(actual))}
+	}
+
+	return nil
+}
+
+// Matcher returns a Matcher which matches fields with keys declared sensitive.
+// It can be used in a RedactionRule to redact fields created without Sensitive.
+func (s *Schema) Matcher() Matcher {
+	return func(f Field) bool {
+		k, ok := s.Lookup(f.Key)
+
+		return ok && k.Sensitive
+	}
+}
+
+// JSONSchema returns a JSON Schema describing a JSON object with the declared fields
+// as produced by the default JSON encoder.
+//
+// Values of sensitive keys are described as strings since they are written redacted,
+// errors may also be objects since errors carrying fields are written as objects,
+// and floats may also be strings since non-finite values are written as strings.
+func (s *Schema) JSONSchema() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	properties := make(map[string]interface{}, len(s.keys))
+	for key, k := range s.keys {
+		property := jsonSchemaType(k.Type)
+		if k.Sensitive {
+			property = map[string]interface{}{"type": "string"}
+		}
+		if k.Description != "" {
+			property["description"] = k.Description
+		}
+		property["x-ctxf-type"] = typeName(k.Type)
+		if k.Sensitive {
+			property["x-ctxf-sensitive"] = true
+		}
+		if k.Cardinality != CardinalityUnspecified {
+			property["x-ctxf-cardinality"] = k.Cardinality.String()
+		}
+		properties[key] = property
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": !s.Strict,
+	})
+}
+
+// SetSchema sets the Schema which fields passed to New and With are validated against.
+// Violations are passed to report, they are dropped if report is nil.
+// Fields extracted by propagators come from remote parties and are not validated.
+// Passing nil schema disables validation.
+func SetSchema(schema *Schema, report func(error)) {
+	if schema == nil {
+		schemaValidation.Store(nil)
+
+		return
+	}
+
+	schemaValidation.Store(&validation{schema, report})
+}
+
+type validation struct {
+	schema *Schema
+	report func(error)
+}
+
+var schemaValidation atomic.Pointer[validation]
+
+func validate(fields []Field) {
+	v := schemaValidation.Load()
+	if v == nil {
+		return
+	}
+
+	if v.report == nil {
+		return
+	}
+
+	if err := v.schema.Validate(fields...); err != nil {
+		v.report(err)
+	}
+}
+
+// ReserveKeys reserves keys of fields added by the package and its middleware.
+// Fields with reserved keys are not validated against a Schema and
+// are not accepted from remote parties by propagators.
+func ReserveKeys(keys ...string) {
+	for _, key := range keys {
+		reservedKeys.Store(key, struct{}{})
+	}
+}
+
+// IsReservedKey reports whether the key is reserved by ReserveKeys.
+func IsReservedKey(key string) bool {
+	_, ok := reservedKeys.Load(key)
+
+	return ok
+}
+
+var reservedKeys sync.Map
+
+func init() {
+	ReserveKeys(TaskKey)
+}
+
+func typeName(t valf.Type) string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("type(%d)", int(t))
+}
+
+var typeNames = map[valf.Type]string{
+	valf.TypeNone:      "none",
+	valf.TypeAny:       "any",
+	valf.TypeArray:     "array",
+	valf.TypeObject:    "object",
+	valf.TypeFormatter: "formatter",
+	valf.TypeBool:      kindBool,
+	valf.TypeInt:       kindInt,
+	valf.TypeInt8:      kindInt8,
+	valf.TypeInt16:     kindInt16,
+	valf.TypeInt32:     kindInt32,
+	valf.TypeInt64:     kindInt64,
+	valf.TypeUint:      kindUint,
+	valf.TypeUint8:     kindUint8,
+	valf.TypeUint16:    kindUint16,
+	valf.TypeUint32:    kindUint32,
+	valf.TypeUint64:    kindUint64,
+	valf.TypeFloat32:   kindFloat32,
+	valf.TypeFloat64:   kindFloat64,
+	valf.TypeDuration:  kindDuration,
+	valf.TypeTime:      kindTime,
+	valf.TypeError:     "error",
+	valf.TypeString:    kindString,
+	valf.TypeStringer:  "stringer",
+	valf.TypeBytes:     kindBytes,
+	valf.TypeBools:     "bools",
+	valf.TypeInts:      "ints",
+	valf.TypeInts8:     "ints8",
+	valf.TypeInts16:    "ints16",
+	valf.TypeInts32:    "ints32",
+	valf.TypeInts64:    "ints64",
+	valf.TypeUints:     "uints",
+	valf.TypeUints8:    "uints8",
+	valf.TypeUints16:   "uints16",
+	valf.TypeUints32:   "uints32",
+	valf.TypeUints64:   "uints64",
+	valf.TypeFloats32:  "floats32",
+	valf.TypeFloats64:  "floats64",
+	valf.TypeDurations: "durations",
+	valf.TypeStrings:   "strings",
+}
+
+func jsonSchemaType(t valf.Type) map[string]interface{} {
+	switch t {
+	case valf.TypeNone:
+		return map[string]interface{}{"type": "null"}
+	case valf.TypeBool:
+		return map[string]interface{}{"type": "boolean"}
+	case valf.TypeInt, valf.TypeInt8, valf.TypeInt16, valf.TypeInt32, valf.TypeInt64,
+		valf.TypeUint, valf.TypeUint8, valf.TypeUint16, valf.TypeUint32, valf.TypeUint64:
+		return map[string]interface{}{"type": "integer"}
+	case valf.TypeFloat32, valf.TypeFloat64:
+		return map[string]interface{}{"type": floatTypes}
+	case valf.TypeDuration:
+		return map[string]interface{}{"type": "number"}
+	case valf.TypeString, valf.TypeStringer, valf.TypeFormatter:
+		return map[string]interface{}{"type": "string"}
+	case valf.TypeError:
+		return map[string]interface{}{"type": []string{"string", "object"}}
+	case valf.TypeTime:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case valf.TypeBytes:
+		return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	case valf.TypeBools:
+		return arraySchema("boolean")
+	case valf.TypeInts, valf.TypeInts8, valf.TypeInts16, valf.TypeInts32, valf.TypeInts64,
+		valf.TypeUints, valf.TypeUints8, valf.TypeUints16, valf.TypeUints32, valf.TypeUints64:
+		return arraySchema("integer")
+	case valf.TypeFloats32, valf.TypeFloats64:
+		return arraySchema(floatTypes)
+	case valf.TypeDurations:
+		return arraySchema("number")
+	case valf.TypeStrings:
+		return arraySchema("string")
+	case valf.TypeArray:
+		return map[string]interface{}{"type": "array"}
+	case valf.TypeObject:
+		return map[string]interface{}{"type": "object"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func arraySchema(items interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": items}}
+}
+
+// floatTypes are JSON types of float values, non-finite values are encoded as strings.
+var floatTypes = []string{"number", "string"}
@@ -0,0 +1,237 @@
+package ctxf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pamburus/valf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSchema() *Schema {
+	return NewSchema(
+		KeySchema{Key: "user_id", Type: valf.TypeInt64, Description: "User identifier", Cardinality: CardinalityHigh},
+		KeySchema{Key: "method", Type: valf.TypeString, Cardinality: CardinalityLow},
+		KeySchema{Key: "token", Type: valf.TypeString, Sensitive: true},
+		KeySchema{Key: "started", Type: valf.TypeTime},
+		KeySchema{Key: "tags", Type: valf.TypeStrings},
+	)
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s := newTestSchema()
+
+	assert.NoError(t, s.Validate(Int64("user_id", 1), String("method", "GET"), Secret("token", "t"), String("other", "x")))
+	assert.NoError(t, s.Validate(LazyString("user_id", func() string { panic("must not be called") })))
+
+	err := s.Validate(String("user_id", "1"), String("token", "t"), Int("method", 1))
+	require.Error(t, err)
+	assert.Equal(t, `ctxf: field "user_id": expected int64, got string
+ctxf: field "token": declared sensitive but created without Sensitive
+ctxf: field "method": expected string, got int`, err.Error())
+
+	var serr *SchemaError
+	require.ErrorAs(t, err, &serr)
+	assert.Equal(t, "user_id", serr.Key)
+
+	s.Strict = true
+	assert.EqualError(t, s.Validate(String("other", "x")), `ctxf: field "other": undeclared key`)
+}
+
+func TestSchemaRegister(t *testing.T) {
+	s := newTestSchema()
+	assert.EqualError(t, s.Register(KeySchema{Key: "a"}, KeySchema{Key: "user_id"}), `ctxf: field "user_id": already declared`)
+	_, ok := s.Lookup("a")
+	assert.False(t, ok)
+
+	assert.EqualError(t, s.Register(KeySchema{Key: "b"}, KeySchema{Key: "b"}), `ctxf: field "b": already declared`)
+
+	require.NoError(t, s.Register(KeySchema{Key: "a", Type: valf.TypeBool}))
+	k, ok := s.Lookup("a")
+	require.True(t, ok)
+	assert.Equal(t, valf.TypeBool, k.Type)
+
+	assert.Panics(t, func() {
+		NewSchema(KeySchema{Key: "a"}, KeySchema{Key: "a"})
+	})
+}
+
+func TestSchemaMatcher(t *testing.T) {
+	p := NewRedactionPolicy(RedactionRule{newTestSchema().Matcher(), Mask("***")})
+	assert.Equal(t, []Field{String("token", "***"), String("method", "GET")}, p.Redact([]Field{String("token", "t"), String("method", "GET")}))
+}
+
+func TestSetSchema(t *testing.T) {
+	var reported []error
+	SetSchema(newTestSchema(), func(err error) {
+		reported = append(reported, err)
+	})
+	defer SetSchema(nil, nil)
+
+	ctx := New(context.Background(), Int64("user_id", 1))
+	ctx.With(String("user_id", "1"))
+	require.Len(t, reported, 1)
+	assert.EqualError(t, reported[0], `ctxf: field "user_id": expected int64, got string`)
+
+	SetSchema(newTestSchema(), nil)
+	assert.NotPanics(t, func() {
+		New(context.Background(), Int("user_id", 1))
+	})
+
+	SetSchema(nil, nil)
+	assert.NotPanics(t, func() {
+		New(context.Background(), Int("user_id", 1))
+	})
+}
+
+func TestSchemaJSONSchemaMatchesEncoder(t *testing.T) {
+	s := NewSchema(
+		KeySchema{Key: "n", Type: valf.TypeInt},
+		KeySchema{Key: "f", Type: valf.TypeFloat64},
+		KeySchema{Key: "fs", Type: valf.TypeFloats64},
+		KeySchema{Key: "d", Type: valf.TypeDuration},
+		KeySchema{Key: "t", Type: valf.TypeTime},
+		KeySchema{Key: "err", Type: valf.TypeError},
+		KeySchema{Key: "plain_err", Type: valf.TypeError},
+		KeySchema{Key: "pin", Type: valf.TypeInt, Sensitive: true},
+		KeySchema{Key: "tags", Type: valf.TypeStrings},
+	)
+	s.Strict = true
+
+	data, err := s.JSONSchema()
+	require.NoError(t, err)
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	ctx := New(context.Background(),
+		Int("n", 1),
+		Float64("f", math.NaN()),
+		Floats64("fs", []float64{0.5, math.Inf(1)}),
+		Duration("d", time.Second),
+		Time("t", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)),
+		NamedError("err", WrapError(context.Background(), errors.New("boom"), Int("code", 1))),
+		NamedError("plain_err", errors.New("boom")),
+		Sensitive("pin", valf.Int(1234)),
+		Strings("tags", []string{"a"}),
+	)
+	require.NoError(t, s.Validate(Fields(ctx)...))
+
+	data, err = json.Marshal(ctx)
+	require.NoError(t, err)
+	var value interface{}
+	require.NoError(t, json.Unmarshal(data, &value))
+
+	assert.NoError(t, checkJSONSchema("", schema, value))
+}
+
+// checkJSONSchema checks the value against the subset of JSON Schema produced by Schema.JSONSchema.
+func checkJSONSchema(path string, schema map[string]interface{}, value interface{}) error {
+	if types, ok := schema["type"]; ok && !jsonTypeMatches(types, value) {
+		return fmt.Errorf("%s: %#v does not match type %v", path, value, types)
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i := range v {
+				if err := checkJSONSchema(fmt.Sprintf("%s[%d]", path, i), items, v[i]); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, member := range v {
+			property, ok := properties[key].(map[string]interface{})
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s.%s: additional property", path, key)
+				}
+
+				continue
+			}
+			if err := checkJSONSchema(path+"."+key, property, member); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func jsonTypeMatches(types interface{}, value interface{}) bool {
+	if list, ok := types.([]interface{}); ok {
+		for _, t := range list {
+			if jsonTypeMatches(t, value) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return types == "null"
+	case bool:
+		return types == "boolean"
+	case float64:
+		return types == "number" || types == "integer" && v == math.Trunc(v)
+	case string:
+		return types == "string"
+	case []interface{}:
+		return types == "array"
+	default:
+		return types == "object"
+	}
+}
+
+func TestSchemaJSONSchema(t *testing.T) {
+	data, err := newTestSchema().JSONSchema()
+	require.NoError(t, err)
+
+	var actual map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &actual))
+	var expected map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"additionalProperties": true,
+		"properties": {
+			"user_id": {"type": "integer", "description": "User identifier", "x-ctxf-type": "int64", "x-ctxf-cardinality": "high"},
+			"method": {"type": "string", "x-ctxf-type": "string", "x-ctxf-cardinality": "low"},
+			"token": {"type": "string", "x-ctxf-type": "string", "x-ctxf-sensitive": true},
+			"started": {"type": "string", "format": "date-time", "x-ctxf-type": "time"},
+			"tags": {"type": "array", "items": {"type": "string"}, "x-ctxf-type": "strings"}
+		}
+	}`), &expected))
+	assert.Equal(t, expected, actual)
+}
+
+func TestSetSchemaSkipsReservedAndExtracted(t *testing.T) {
+	s := newTestSchema()
+	s.Strict = true
+
+	var reported []error
+	SetSchema(s, func(err error) {
+		reported = append(reported, err)
+	})
+	defer SetSchema(nil, nil)
+
+	header := "user_id=x,other=1;ctxf-type=int"
+	ctx := NewBaggagePropagator("user_id", "other").Extract(context.Background(), MapCarrier{BaggageHeader: header})
+	ctx = ctx.With(String(TaskKey, "worker"))
+	assert.Empty(t, reported)
+	assert.Equal(t, `{"user_id":"x","other":1,"task":"worker"}`, marshal(t, ctx))
+
+	SetSchema(s, nil)
+	assert.NotPanics(t, func() {
+		NewBaggagePropagator("user_id", "other").Extract(context.Background(), MapCarrier{BaggageHeader: header})
+	})
+}
@@ -0,0 +1,346 @@
+package ctxf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/pamburus/ctxf/internal/visit"
+	"github.com/pamburus/valf"
+)
+
+// SlogHandler is a slog.Handler that adds fields associated with the context
+// to each record before passing it to the inner handler.
+type SlogHandler struct {
+	inner slog.Handler
+}
+
+// NewSlogHandler returns a new SlogHandler wrapping the given handler.
+func NewSlogHandler(inner slog.Handler) *SlogHandler {
+	return &SlogHandler{inner}
+}
+
+// Enabled delegates the call to the inner handler.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+// Handle adds fields associated with the ctx to the record and passes it to the inner handler.
+// Fields with duplicate keys are reduced to the last value.
+// The RedactionPolicy set by SetRedactionPolicy is applied to the fields.
+// Attributes of the record containing []Field values are expanded to groups,
+// including attributes nested in groups.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := Export(Unique(Fields(ctx)))
+	if len(fields) == 0 && !hasFieldAttrs(record) {
+		return h.inner.Handle(ctx, record)
+	}
+
+	r := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	for i := range fields {
+		r.AddAttrs(fields[i].Attr())
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		r.AddAttrs(expandAttr(attr))
+
+		return true
+	})
+
+	return h.inner.Handle(ctx, r)
+}
+
+// WithAttrs returns a new SlogHandler wrapping the inner handler with the given attributes.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded := make([]slog.Attr, len(attrs))
+	for i := range attrs {
+		expanded[i] = expandAttr(attrs[i])
+	}
+
+	return &SlogHandler{h.inner.WithAttrs(expanded)}
+}
+
+// WithGroup returns a new SlogHandler wrapping the inner handler with the given group.
+//
+// Note that fields associated with the context are added to the record and
+// so they also get nested into the group.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	return &SlogHandler{h.inner.WithGroup(name)}
+}
+
+// Inner returns the inner handler.
+func (h *SlogHandler) Inner() slog.Handler {
+	return h.inner
+}
+
+// Attr converts the Field to slog.Attr.
+// Note that Attr does not apply the RedactionPolicy, use LogValue or FieldList if it is needed.
+func (f Field) Attr() slog.Attr {
+	return slog.Attr{Key: f.Key, Value: SlogValue(f.Value)}
+}
+
+// LogValue implements slog.LogValuer.
+// The Field is represented as a group containing the single attribute.
+// The RedactionPolicy set by SetRedactionPolicy is applied to the Field.
+func (f Field) LogValue() slog.Value {
+	return slog.GroupValue(slogAttrs(Export([]Field{f}))...)
+}
+
+// FieldList is a slice of fields which implements slog.LogValuer.
+type FieldList []Field
+
+// LogValue implements slog.LogValuer.
+// The FieldList is represented as a group containing an attribute for each field.
+// The RedactionPolicy set by SetRedactionPolicy is applied to the fields.
+func (l FieldList) LogValue() slog.Value {
+	return slog.GroupValue(slogAttrs(Export(l))...)
+}
+
+// SlogValue converts valf.Value to slog.Value.
+// Arrays and objects are converted to groups, elements of arrays are keyed by their indexes.
+func SlogValue(v valf.Value) slog.Value {
+	var visitor slogValueVisitor
+	v.AcceptVisitor(&visitor)
+
+	return visitor.value
+}
+
+func slogAttrs(fields []Field) []slog.Attr {
+	result := make([]slog.Attr, len(fields))
+	for i := range fields {
+		result[i] = fields[i].Attr()
+	}
+
+	return result
+}
+
+func hasFieldAttrs(record slog.Record) bool {
+	found := false
+	record.Attrs(func(attr slog.Attr) bool {
+		found = hasFields(attr)
+
+		return !found
+	})
+
+	return found
+}
+
+// hasFields reports whether the attr holds a []Field value, possibly nested in groups.
+func hasFields(attr slog.Attr) bool {
+	switch attr.Value.Kind() {
+	case slog.KindAny:
+		_, ok := attr.Value.Any().([]Field)
+
+		return ok
+	case slog.KindGroup:
+		for _, a := range attr.Value.Group() {
+			if hasFields(a) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// expandAttr expands []Field values of the attr and of attributes nested in its groups.
+func expandAttr(attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindAny:
+		if fields, ok := attr.Value.Any().([]Field); ok {
+			attr.Value = FieldList(fields).LogValue()
+		}
+	case slog.KindGroup:
+		if hasFields(attr) {
+			attrs := attr.Value.Group()
+			expanded := make([]slog.Attr, len(attrs))
+			for i := range attrs {
+				expanded[i] = expandAttr(attrs[i])
+			}
+			attr.Value = slog.GroupValue(expanded...)
+		}
+	}
+
+	return attr
+}
+
+type slogValueVisitor struct {
+	value slog.Value
+}
+
+func (v *slogValueVisitor) VisitNone() {
+	v.value = slog.Value{}
+}
+
+func (v *slogValueVisitor) VisitAny(value interface{}) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitBool(value bool) {
+	v.value = slog.BoolValue(value)
+}
+
+func (v *slogValueVisitor) VisitInt(value int) {
+	v.value = slog.IntValue(value)
+}
+
+func (v *slogValueVisitor) VisitInt8(value int8) {
+	v.value = slog.Int64Value(int64(value))
+}
+
+func (v *slogValueVisitor) VisitInt16(value int16) {
+	v.value = slog.Int64Value(int64(value))
+}
+
+func (v *slogValueVisitor) VisitInt32(value int32) {
+	v.value = slog.Int64Value(int64(value))
+}
+
+func (v *slogValueVisitor) VisitInt64(value int64) {
+	v.value = slog.Int64Value(value)
+}
+
+func (v *slogValueVisitor) VisitUint(value uint) {
+	v.value = slog.Uint64Value(uint64(value))
+}
+
+func (v *slogValueVisitor) VisitUint8(value uint8) {
+	v.value = slog.Uint64Value(uint64(value))
+}
+
+func (v *slogValueVisitor) VisitUint16(value uint16) {
+	v.value = slog.Uint64Value(uint64(value))
+}
+
+func (v *slogValueVisitor) VisitUint32(value uint32) {
+	v.value = slog.Uint64Value(uint64(value))
+}
+
+func (v *slogValueVisitor) VisitUint64(value uint64) {
+	v.value = slog.Uint64Value(value)
+}
+
+func (v *slogValueVisitor) VisitFloat32(value float32) {
+	v.value = slog.Float64Value(float64(value))
+}
+
+func (v *slogValueVisitor) VisitFloat64(value float64) {
+	v.value = slog.Float64Value(value)
+}
+
+func (v *slogValueVisitor) VisitDuration(value time.Duration) {
+	v.value = slog.DurationValue(value)
+}
+
+func (v *slogValueVisitor) VisitTime(value time.Time) {
+	v.value = slog.TimeValue(value)
+}
+
+func (v *slogValueVisitor) VisitError(value error) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitString(value string) {
+	v.value = slog.StringValue(value)
+}
+
+func (v *slogValueVisitor) VisitStringer(value fmt.Stringer) {
+	if value == nil {
+		v.value = slog.Value{}
+
+		return
+	}
+
+	v.value = slog.StringValue(value.String())
+}
+
+func (v *slogValueVisitor) VisitFormatter(verb string, value interface{}) {
+	v.value = slog.StringValue(fmt.Sprintf(verb, value))
+}
+
+func (v *slogValueVisitor) VisitBytes(value []byte) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitBools(value []bool) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitInts(value []int) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitInts8(value []int8) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitInts16(value []int16) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitInts32(value []int32) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitInts64(value []int64) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitUints(value []uint) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitUints8(value []uint8) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitUints16(value []uint16) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitUints32(value []uint32) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitUints64(value []uint64) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitFloats32(value []float32) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitFloats64(value []float64) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitDurations(value []time.Duration) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitStrings(value []string) {
+	v.value = slog.AnyValue(value)
+}
+
+func (v *slogValueVisitor) VisitArray(value valf.ValueArray) {
+	var attrs []slog.Attr
+	if value != nil {
+		value.AcceptArrayVisitor(visit.ArrayFunc(func(item valf.Value) {
+			attrs = append(attrs, slog.Attr{Key: strconv.Itoa(len(attrs)), Value: SlogValue(item)})
+		}))
+	}
+
+	v.value = slog.GroupValue(attrs...)
+}
+
+func (v *slogValueVisitor) VisitObject(value valf.ValueObject) {
+	var attrs []slog.Attr
+	if value != nil {
+		value.AcceptObjectVisitor(visit.ObjectFunc(func(key string, item valf.Value) {
+			attrs = append(attrs, slog.Attr{Key: key, Value: SlogValue(item)})
+		}))
+	}
+
+	v.value = slog.GroupValue(attrs...)
+}
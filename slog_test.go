@@ -0,0 +1,173 @@
+package ctxf
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pamburus/valf"
+	"github.com/stretchr/testify/assert"
+)
+
+type testObject []Field
+
+func (o testObject) AcceptObjectVisitor(v valf.ObjectVisitor) {
+	for _, f := range o {
+		v.VisitField(f.Key, f.Value)
+	}
+}
+
+type testArray []valf.Value
+
+func (a testArray) AcceptArrayVisitor(v valf.ArrayVisitor) {
+	for _, item := range a {
+		v.VisitElement(item)
+	}
+}
+
+func newTestSlogLogger(buf *bytes.Buffer) *slog.Logger {
+	inner := slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	})
+
+	return slog.New(NewSlogHandler(inner))
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestSlogLogger(&buf)
+
+	ctx := New(context.Background(), String("user", "alice"), Int("id", 42))
+	logger.InfoContext(ctx, "hello", "extra", true)
+	assert.Equal(t, "level=INFO msg=hello user=alice id=42 extra=true\n", buf.String())
+}
+
+func TestSlogHandlerWithoutFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestSlogLogger(&buf)
+
+	logger.InfoContext(context.Background(), "hello")
+	assert.Equal(t, "level=INFO msg=hello\n", buf.String())
+}
+
+func TestSlogHandlerWithAttrsAndGroup(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestSlogLogger(&buf).With("service", "test").WithGroup("g")
+
+	ctx := New(context.Background(), Bool("bool", true))
+	logger.InfoContext(ctx, "hello")
+	assert.Equal(t, "level=INFO msg=hello service=test g.bool=true\n", buf.String())
+}
+
+func TestSlogHandlerFieldsAttr(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestSlogLogger(&buf)
+
+	ctx := New(context.Background(), String("user", "alice"))
+	logger.Info("hello", slog.Any("ctx", Fields(ctx)))
+	assert.Equal(t, "level=INFO msg=hello ctx.user=alice\n", buf.String())
+}
+
+func TestSlogHandlerFieldsAttrInGroup(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestSlogLogger(&buf)
+
+	fields := []Field{String("user", "alice"), Secret("token", "t0k3n")}
+	logger.Info("hello", slog.Group("req", slog.Any("ctx", fields)))
+	logger.WithGroup("g").With(slog.Group("req", slog.Any("ctx", fields))).Info("hello")
+	assert.Equal(t,
+		"level=INFO msg=hello req.ctx.user=alice req.ctx.token=[REDACTED]\n"+
+			"level=INFO msg=hello g.req.ctx.user=alice g.req.ctx.token=[REDACTED]\n",
+		buf.String(),
+	)
+}
+
+func TestSlogLogValuer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	}))
+
+	logger.Info("hello",
+		slog.Any("field", Int("int", 1)),
+		slog.Any("fields", FieldList{String("a", "x"), Duration("b", time.Second)}),
+	)
+	assert.Equal(t, "level=INFO msg=hello field.int=1 fields.a=x fields.b=1s\n", buf.String())
+}
+
+func TestSlogValue(t *testing.T) {
+	now := time.Now()
+
+	tcs := []struct {
+		Name     string
+		Value    valf.Value
+		Expected slog.Value
+	}{
+		{"None", valf.Value{}, slog.Value{}},
+		{"Bool", valf.Bool(true), slog.BoolValue(true)},
+		{"Int", valf.Int(-1), slog.Int64Value(-1)},
+		{"Int8", valf.Int8(-8), slog.Int64Value(-8)},
+		{"Int16", valf.Int16(-16), slog.Int64Value(-16)},
+		{"Int32", valf.Int32(-32), slog.Int64Value(-32)},
+		{"Int64", valf.Int64(-64), slog.Int64Value(-64)},
+		{"Uint", valf.Uint(1), slog.Uint64Value(1)},
+		{"Uint8", valf.Uint8(8), slog.Uint64Value(8)},
+		{"Uint16", valf.Uint16(16), slog.Uint64Value(16)},
+		{"Uint32", valf.Uint32(32), slog.Uint64Value(32)},
+		{"Uint64", valf.Uint64(64), slog.Uint64Value(64)},
+		{"Float32", valf.Float32(0.5), slog.Float64Value(0.5)},
+		{"Float64", valf.Float64(0.25), slog.Float64Value(0.25)},
+		{"Duration", valf.Duration(time.Second), slog.DurationValue(time.Second)},
+		{"Time", valf.Time(now), slog.TimeValue(now)},
+		{"String", valf.String("s"), slog.StringValue("s")},
+		{"Stringer", valf.Stringer(time.Second), slog.StringValue("1s")},
+		{"NilStringer", valf.Stringer(nil), slog.Value{}},
+		{"Formatter", valf.Formatter("%03d", 7), slog.StringValue("007")},
+		{
+			"Array",
+			valf.Array(testArray{valf.Int(1), valf.String("x")}),
+			slog.GroupValue(slog.Int("0", 1), slog.String("1", "x")),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.True(t, tc.Expected.Equal(SlogValue(tc.Value)), "%v != %v", tc.Expected, SlogValue(tc.Value))
+		})
+	}
+}
+
+func TestSlogValueSlice(t *testing.T) {
+	value := SlogValue(valf.Strings([]string{"a", "b"}))
+	assert.Equal(t, slog.KindAny, value.Kind())
+	assert.Equal(t, []string{"a", "b"}, value.Any())
+}
+
+func TestSlogValueObject(t *testing.T) {
+	value := SlogValue(valf.Object(testObject{String("a", "x"), Object("b", testObject{Int("c", 1)})}))
+	expected := slog.GroupValue(slog.String("a", "x"), slog.Group("b", slog.Int("c", 1)))
+	assert.True(t, expected.Equal(value), "%v != %v", expected, value)
+}
+
+func TestSlogHandlerDuplicateFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestSlogLogger(&buf)
+
+	ctx := New(context.Background(), Int("id", 1), String("user", "alice")).With(Int("id", 2))
+	logger.InfoContext(ctx, "hello")
+	assert.Equal(t, "level=INFO msg=hello id=2 user=alice\n", buf.String())
+}
@@ -0,0 +1,179 @@
+package ctxf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// TaskKey is the key of the field holding name of a task started by Go, GoDetached or TaskGroup.
+const TaskKey = "task"
+
+// ErrTaskExited is returned by a task which called runtime.Goexit, e.g. with testing.T.FailNow.
+var ErrTaskExited = errors.New("ctxf: task exited with runtime.Goexit")
+
+// PanicError is an error returned by a task which panicked.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the goroutine at the moment of the panic.
+	Stack []byte
+	// Fields are the fields associated with the task context.
+	Fields []Field
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+
+	return err
+}
+
+// Go runs fn in a new goroutine with a Context having fields associated with the ctx
+// and a field with TaskKey holding the given name, unless the name is empty.
+// The Context is canceled when the ctx is canceled.
+//
+// The error returned by fn is sent to the returned channel.
+// A panic in fn is recovered and sent as *PanicError,
+// ErrTaskExited is sent if fn calls runtime.Goexit.
+func Go(ctx context.Context, name string, fn func(Context) error) <-chan error {
+	return goTask(DecodeOptional(ctx), name, fn)
+}
+
+// GoDetached behaves like Go but the Context passed to fn is not canceled
+// when the ctx is canceled. See Context.Detach for details.
+func GoDetached(ctx context.Context, name string, fn func(Context) error) <-chan error {
+	return goTask(WithoutCancel(ctx), name, fn)
+}
+
+// TaskGroup is a collection of tasks running in goroutines
+// with fields associated with a common context.
+//
+// Like errgroup.Group, it cancels the common context as soon as a task returns
+// an error or panics, and Wait returns the first error.
+// A task calling runtime.Goexit fails with ErrTaskExited.
+// The zero value is a TaskGroup with a common Context derived from context.Background().
+type TaskGroup struct {
+	ctx    Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+	init   sync.Once
+	once   sync.Once
+	err    error
+	sem    chan struct{}
+}
+
+// NewTaskGroup returns a new TaskGroup and its common Context derived from the ctx.
+// The Context is canceled when the ctx is canceled, a task fails or Wait returns.
+func NewTaskGroup(ctx context.Context) (*TaskGroup, Context) {
+	return newTaskGroup(DecodeOptional(ctx))
+}
+
+// NewDetachedTaskGroup behaves like NewTaskGroup but the common Context
+// is not canceled when the ctx is canceled.
+func NewDetachedTaskGroup(ctx context.Context) (*TaskGroup, Context) {
+	return newTaskGroup(WithoutCancel(ctx))
+}
+
+func newTaskGroup(c Context) (*TaskGroup, Context) {
+	g := &TaskGroup{}
+	g.ctx, g.cancel = c.WithCancelCause()
+
+	return g, g.ctx
+}
+
+// SetLimit limits the number of tasks running at the same time to n.
+// A negative n removes the limit. It must not be called while tasks are running.
+func (g *TaskGroup) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+	} else {
+		g.sem = make(chan struct{}, n)
+	}
+}
+
+// Go runs fn in a new goroutine with the common Context having a field with TaskKey
+// holding the given name, unless the name is empty.
+// It blocks until the task can be started if the limit set by SetLimit is reached.
+func (g *TaskGroup) Go(name string, fn func(Context) error) {
+	g.lazyInit()
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+
+	c := g.ctx
+	if name != "" {
+		c = c.With(String(TaskKey, name))
+	}
+
+	g.wg.Add(1)
+	go func() {
+		err := ErrTaskExited
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+		defer func() {
+			if err != nil {
+				g.once.Do(func() {
+					g.err = err
+					g.cancel(err)
+				})
+			}
+		}()
+
+		err = runTask(c, fn)
+	}()
+}
+
+// Wait blocks until all tasks are finished and returns the first error
+// returned by a task, if any.
+func (g *TaskGroup) Wait() error {
+	g.lazyInit()
+	g.wg.Wait()
+	g.cancel(g.err)
+
+	return g.err
+}
+
+// lazyInit makes the zero value usable.
+func (g *TaskGroup) lazyInit() {
+	g.init.Do(func() {
+		if g.cancel == nil {
+			g.ctx, g.cancel = New(context.Background()).WithCancelCause()
+		}
+	})
+}
+
+func goTask(c Context, name string, fn func(Context) error) <-chan error {
+	if name != "" {
+		c = c.With(String(TaskKey, name))
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		err := ErrTaskExited
+		defer func() { result <- err }()
+
+		err = runTask(c, fn)
+	}()
+
+	return result
+}
+
+func runTask(c Context, fn func(Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{r, debug.Stack(), c.Fields()}
+		}
+	}()
+
+	return fn(c)
+}
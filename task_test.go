@@ -0,0 +1,153 @@
+package ctxf
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGo(t *testing.T) {
+	ctx := New(context.Background(), String("request_id", "r1"))
+	err := <-Go(ctx, "audit", func(ctx Context) error {
+		assert.Equal(t, []Field{String("request_id", "r1"), String(TaskKey, "audit")}, ctx.Fields())
+
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+
+	err = <-Go(ctx, "", func(ctx Context) error {
+		assert.Equal(t, []Field{String("request_id", "r1")}, ctx.Fields())
+
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestGoPanic(t *testing.T) {
+	cause := errors.New("boom")
+	err := <-Go(New(context.Background(), String("request_id", "r1")), "worker", func(ctx Context) error {
+		panic(cause)
+	})
+
+	var perr *PanicError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, "panic: boom", perr.Error())
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, []Field{String("request_id", "r1"), String(TaskKey, "worker")}, perr.Fields)
+	assert.Contains(t, string(perr.Stack), "TestGoPanic")
+}
+
+func TestGoexit(t *testing.T) {
+	err := <-Go(context.Background(), "exit", func(Context) error {
+		runtime.Goexit()
+
+		return nil
+	})
+	assert.Equal(t, ErrTaskExited, err)
+
+	g, _ := NewTaskGroup(context.Background())
+	g.Go("exit", func(Context) error {
+		runtime.Goexit()
+
+		return nil
+	})
+	assert.Equal(t, ErrTaskExited, g.Wait())
+}
+
+func TestTaskGroupZeroValue(t *testing.T) {
+	var g TaskGroup
+	var n atomic.Int32
+	g.Go("worker", func(ctx Context) error {
+		n.Add(1)
+		assert.Equal(t, []Field{String(TaskKey, "worker")}, ctx.Fields())
+
+		return nil
+	})
+	assert.NoError(t, g.Wait())
+	assert.Equal(t, int32(1), n.Load())
+
+	var empty TaskGroup
+	assert.NoError(t, empty.Wait())
+}
+
+func TestGoCancellation(t *testing.T) {
+	ctx, cancel := New(context.Background(), String("request_id", "r1")).WithCancel()
+	cancel()
+
+	err := <-Go(ctx, "bound", func(ctx Context) error {
+		return ctx.Err()
+	})
+	assert.Equal(t, context.Canceled, err)
+
+	err = <-GoDetached(ctx, "detached", func(ctx Context) error {
+		assert.Equal(t, []Field{String("request_id", "r1"), String(TaskKey, "detached")}, ctx.Fields())
+
+		return ctx.Err()
+	})
+	assert.NoError(t, err)
+}
+
+func TestTaskGroup(t *testing.T) {
+	g, ctx := NewTaskGroup(New(context.Background(), String("request_id", "r1")))
+	g.SetLimit(2)
+
+	var running, peak atomic.Int32
+	for _, name := range []string{"a", "b", "c", "d"} {
+		name := name
+		g.Go(name, func(ctx Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+
+			field, ok := ctx.Lookup(TaskKey)
+			assert.True(t, ok)
+			assert.Equal(t, String(TaskKey, name), field)
+
+			return nil
+		})
+	}
+	assert.NoError(t, g.Wait())
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+func TestTaskGroupError(t *testing.T) {
+	g, ctx := NewTaskGroup(New(context.Background(), String("request_id", "r1")))
+
+	g.Go("failing", func(ctx Context) error {
+		panic("boom")
+	})
+	g.Go("waiting", func(ctx Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	})
+
+	err := g.Wait()
+	var perr *PanicError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, "boom", perr.Value)
+	assert.Equal(t, err, ctx.Cause())
+}
+
+func TestDetachedTaskGroup(t *testing.T) {
+	parent, cancel := New(context.Background(), String("request_id", "r1")).WithCancel()
+	g, ctx := NewDetachedTaskGroup(parent)
+	cancel()
+
+	g.Go("", func(ctx Context) error {
+		return ctx.Err()
+	})
+	assert.NoError(t, g.Wait())
+	assert.Equal(t, []Field{String("request_id", "r1")}, ctx.Fields())
+}
@@ -0,0 +1,219 @@
+package ctxf
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pamburus/valf"
+)
+
+// formatText returns text representation of a scalar value and the name of its kind
+// which can be used to parse the text back with parseText.
+// It returns false if the value is not a scalar.
+func formatText(v valf.Value) (text string, kind string, ok bool) {
+	var visitor textVisitor
+	v.AcceptVisitor(&visitor)
+
+	return visitor.text, visitor.kind, visitor.kind != ""
+}
+
+// valueText returns text representation of a value.
+// Values which are not scalars are represented as JSON.
+func valueText(v valf.Value) string {
+	if text, _, ok := formatText(v); ok {
+		return text
+	}
+
+	return string(jsonEncoder.AppendValue(nil, v))
+}
+
+// parseText parses text produced by formatText back to a value of the given kind.
+func parseText(kind, text string) (valf.Value, bool) {
+	switch kind {
+	case kindString:
+		return valf.String(text), true
+	case kindBool:
+		v, err := strconv.ParseBool(text)
+
+		return valf.Bool(v), err == nil
+	case kindInt:
+		v, err := strconv.ParseInt(text, 10, strconv.IntSize)
+
+		return valf.Int(int(v)), err == nil
+	case kindInt8:
+		v, err := strconv.ParseInt(text, 10, 8)
+
+		return valf.Int8(int8(v)), err == nil
+	case kindInt16:
+		v, err := strconv.ParseInt(text, 10, 16)
+
+		return valf.Int16(int16(v)), err == nil
+	case kindInt32:
+		v, err := strconv.ParseInt(text, 10, 32)
+
+		return valf.Int32(int32(v)), err == nil
+	case kindInt64:
+		v, err := strconv.ParseInt(text, 10, 64)
+
+		return valf.Int64(v), err == nil
+	case kindUint:
+		v, err := strconv.ParseUint(text, 10, strconv.IntSize)
+
+		return valf.Uint(uint(v)), err == nil
+	case kindUint8:
+		v, err := strconv.ParseUint(text, 10, 8)
+
+		return valf.Uint8(uint8(v)), err == nil
+	case kindUint16:
+		v, err := strconv.ParseUint(text, 10, 16)
+
+		return valf.Uint16(uint16(v)), err == nil
+	case kindUint32:
+		v, err := strconv.ParseUint(text, 10, 32)
+
+		return valf.Uint32(uint32(v)), err == nil
+	case kindUint64:
+		v, err := strconv.ParseUint(text, 10, 64)
+
+		return valf.Uint64(v), err == nil
+	case kindFloat32:
+		v, err := strconv.ParseFloat(text, 32)
+
+		return valf.Float32(float32(v)), err == nil
+	case kindFloat64:
+		v, err := strconv.ParseFloat(text, 64)
+
+		return valf.Float64(v), err == nil
+	case kindDuration:
+		v, err := time.ParseDuration(text)
+
+		return valf.Duration(v), err == nil
+	case kindTime:
+		v, err := time.Parse(time.RFC3339Nano, text)
+
+		return valf.Time(v), err == nil
+	case kindBytes:
+		v, err := base64.StdEncoding.DecodeString(text)
+
+		return valf.Bytes(v), err == nil
+	default:
+		return valf.Value{}, false
+	}
+}
+
+const (
+	kindString   = "string"
+	kindBool     = "bool"
+	kindInt      = "int"
+	kindInt8     = "int8"
+	kindInt16    = "int16"
+	kindInt32    = "int32"
+	kindInt64    = "int64"
+	kindUint     = "uint"
+	kindUint8    = "uint8"
+	kindUint16   = "uint16"
+	kindUint32   = "uint32"
+	kindUint64   = "uint64"
+	kindFloat32  = "float32"
+	kindFloat64  = "float64"
+	kindDuration = "duration"
+	kindTime     = "time"
+	kindBytes    = "bytes"
+)
+
+type textVisitor struct {
+	valf.IgnoringVisitor
+	text string
+	kind string
+}
+
+func (v *textVisitor) set(text, kind string) {
+	v.text = text
+	v.kind = kind
+}
+
+func (v *textVisitor) VisitBool(value bool) {
+	v.set(strconv.FormatBool(value), kindBool)
+}
+
+func (v *textVisitor) VisitInt(value int) {
+	v.set(strconv.FormatInt(int64(value), 10), kindInt)
+}
+
+func (v *textVisitor) VisitInt8(value int8) {
+	v.set(strconv.FormatInt(int64(value), 10), kindInt8)
+}
+
+func (v *textVisitor) VisitInt16(value int16) {
+	v.set(strconv.FormatInt(int64(value), 10), kindInt16)
+}
+
+func (v *textVisitor) VisitInt32(value int32) {
+	v.set(strconv.FormatInt(int64(value), 10), kindInt32)
+}
+
+func (v *textVisitor) VisitInt64(value int64) {
+	v.set(strconv.FormatInt(value, 10), kindInt64)
+}
+
+func (v *textVisitor) VisitUint(value uint) {
+	v.set(strconv.FormatUint(uint64(value), 10), kindUint)
+}
+
+func (v *textVisitor) VisitUint8(value uint8) {
+	v.set(strconv.FormatUint(uint64(value), 10), kindUint8)
+}
+
+func (v *textVisitor) VisitUint16(value uint16) {
+	v.set(strconv.FormatUint(uint64(value), 10), kindUint16)
+}
+
+func (v *textVisitor) VisitUint32(value uint32) {
+	v.set(strconv.FormatUint(uint64(value), 10), kindUint32)
+}
+
+func (v *textVisitor) VisitUint64(value uint64) {
+	v.set(strconv.FormatUint(value, 10), kindUint64)
+}
+
+func (v *textVisitor) VisitFloat32(value float32) {
+	v.set(strconv.FormatFloat(float64(value), 'g', -1, 32), kindFloat32)
+}
+
+func (v *textVisitor) VisitFloat64(value float64) {
+	v.set(strconv.FormatFloat(value, 'g', -1, 64), kindFloat64)
+}
+
+func (v *textVisitor) VisitDuration(value time.Duration) {
+	v.set(value.String(), kindDuration)
+}
+
+func (v *textVisitor) VisitTime(value time.Time) {
+	v.set(value.Format(time.RFC3339Nano), kindTime)
+}
+
+func (v *textVisitor) VisitBytes(value []byte) {
+	v.set(base64.StdEncoding.EncodeToString(value), kindBytes)
+}
+
+func (v *textVisitor) VisitString(value string) {
+	v.set(value, kindString)
+}
+
+func (v *textVisitor) VisitError(value error) {
+	if value != nil {
+		v.set(value.Error(), kindString)
+	}
+}
+
+func (v *textVisitor) VisitStringer(value fmt.Stringer) {
+	if value != nil {
+		v.set(value.String(), kindString)
+	}
+}
+
+func (v *textVisitor) VisitFormatter(verb string, value interface{}) {
+	v.set(fmt.Sprintf(verb, value), kindString)
+}
@@ -0,0 +1,58 @@
+package ctxf
+
+import (
+	"context"
+	"runtime/trace"
+)
+
+// TraceCategory is the category of execution tracer log messages holding fields.
+const TraceCategory = "ctxf"
+
+// NewTraceTask creates a runtime/trace task with the given name and returns it
+// along with a Context having fields associated with the ctx and the task.
+// The fields are logged to the task as "key=value" messages if tracing is enabled.
+// The caller is responsible for ending the task.
+func NewTraceTask(ctx context.Context, name string) (Context, *trace.Task) {
+	c := DecodeOptional(ctx)
+
+	var task *trace.Task
+	c.parent, task = trace.NewTask(c.parent, name)
+	logTraceFields(c)
+
+	return c, task
+}
+
+// StartTraceRegion starts a runtime/trace region with the given name
+// and logs fields associated with the ctx as "key=value" messages if tracing is enabled.
+// The caller is responsible for ending the region.
+func StartTraceRegion(ctx context.Context, name string) *trace.Region {
+	region := trace.StartRegion(ctx, name)
+	logTraceFields(DecodeOptional(ctx))
+
+	return region
+}
+
+// WithTraceRegion calls fn within a runtime/trace region with the given name
+// passing it a Context having fields associated with the ctx.
+// The fields are logged as "key=value" messages if tracing is enabled.
+func WithTraceRegion(ctx context.Context, name string, fn func(Context)) {
+	c := DecodeOptional(ctx)
+	trace.WithRegion(c.parent, name, func() {
+		logTraceFields(c)
+		fn(c)
+	})
+}
+
+// logTraceFields logs fields associated with the c to the execution tracer.
+// Sensitive fields are skipped and the RedactionPolicy set by SetRedactionPolicy
+// is applied to the other fields.
+func logTraceFields(c Context) {
+	if !trace.IsEnabled() {
+		return
+	}
+
+	fields := Flatten(exportLabels(c.UniqueFields()))
+	for i := range fields {
+		trace.Log(c, TraceCategory, fields[i].Key+"="+valueText(fields[i].Value))
+	}
+}
@@ -0,0 +1,45 @@
+package ctxf
+
+import (
+	"bytes"
+	"context"
+	"runtime/trace"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, trace.Start(&buf))
+
+	ctx := New(context.Background(), String("tenant", "acme"), Secret("token", "s3cr3t"))
+	ctx, task := NewTraceTask(ctx, "checkout-task")
+	assert.Equal(t, []Field{String("tenant", "acme")}, ctx.Fields()[:1])
+
+	region := StartTraceRegion(ctx.With(Int("attempt", 1)), "payment-region")
+	region.End()
+
+	called := false
+	WithTraceRegion(ctx.With(String("step", "commit")), "commit-region", func(c Context) {
+		called = true
+		assert.Len(t, c.Fields(), 3)
+	})
+	assert.True(t, called)
+
+	task.End()
+	trace.Stop()
+
+	data := buf.Bytes()
+	for _, s := range []string{"checkout-task", "payment-region", "commit-region", TraceCategory, "tenant=acme", "attempt=1", "step=commit"} {
+		assert.True(t, bytes.Contains(data, []byte(s)), "trace does not contain %q", s)
+	}
+	assert.False(t, bytes.Contains(data, []byte("s3cr3t")))
+}
+
+func TestTraceDisabled(t *testing.T) {
+	ctx, task := NewTraceTask(New(context.Background(), String("tenant", "acme")), "task")
+	defer task.End()
+	assert.Equal(t, []Field{String("tenant", "acme")}, ctx.Fields())
+}